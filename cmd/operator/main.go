@@ -3,15 +3,22 @@ package main
 import (
 	"flag"
 	"os"
+	"path/filepath"
 
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+	ctrlwebhook "sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	"github.com/antonjah/static/internal/controller"
+	"github.com/antonjah/static/internal/webhook"
 	staticv1alpha1 "github.com/antonjah/static/pkg/apis/static/v1alpha1"
 )
 
@@ -28,20 +35,67 @@ func init() {
 func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
+	var enableWebhooks bool
+	var webhookPort int
+	var webhookConfig string
+	var certOptions webhook.CertOptions
 
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false, "Enable leader election for controller manager.")
+	flag.BoolVar(&enableWebhooks, "enable-webhooks", false, "Serve the validating webhooks for Static and StaticAPI resources.")
+	flag.IntVar(&webhookPort, "webhook-port", 9443, "The port the webhook server binds to.")
+	flag.StringVar(&certOptions.CertDir, "webhook-cert-dir", filepath.Join(os.TempDir(), "k8s-webhook-server", "serving-certs"),
+		"The directory the webhook certificate is read from; generated if it contains none.")
+	flag.StringVar(&certOptions.ServiceName, "webhook-service", "static-operator-webhook", "The Service in front of the webhook server.")
+	flag.StringVar(&certOptions.Namespace, "webhook-namespace", os.Getenv("POD_NAMESPACE"), "The namespace of the webhook Service and certificate Secret.")
+	flag.StringVar(&certOptions.SecretName, "webhook-secret", "static-operator-webhook-cert", "The Secret the generated webhook certificate is kept in.")
+	flag.StringVar(&webhookConfig, "webhook-config", "static-operator", "The ValidatingWebhookConfiguration the generated CA is injected into.")
 	flag.Parse()
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&zap.Options{
 		Development: true,
 	})))
 
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
+	restConfig := ctrl.GetConfigOrDie()
+	ctx := ctrl.SetupSignalHandler()
+
+	var caBundle []byte
+	var certClient client.Client
+	if enableWebhooks {
+		if certOptions.Namespace == "" {
+			setupLog.Error(nil, "--webhook-namespace or POD_NAMESPACE must be set to serve the webhooks")
+			os.Exit(1)
+		}
+		// the manager client reads from its cache, which is not started yet and does not
+		// hold the certificate Secret
+		var err error
+		if certClient, err = client.New(restConfig, client.Options{Scheme: scheme}); err != nil {
+			setupLog.Error(err, "unable to create client")
+			os.Exit(1)
+		}
+		if caBundle, err = webhook.EnsureCertificate(ctx, certClient, certOptions); err != nil {
+			setupLog.Error(err, "unable to bootstrap webhook certificate")
+			os.Exit(1)
+		}
+	}
+
+	// only the ConfigMaps and Secrets StaticAPI bodies may be read from are cached
+	bodySources := labels.SelectorFromSet(labels.Set{staticv1alpha1.BodySourceLabel: "true"})
+	mgr, err := ctrl.NewManager(restConfig, ctrl.Options{
 		Scheme: scheme,
+		Cache: cache.Options{
+			ByObject: map[client.Object]cache.ByObject{
+				&corev1.ConfigMap{}: {Label: bodySources},
+				&corev1.Secret{}:    {Label: bodySources},
+			},
+		},
 		Metrics: metricsserver.Options{
 			BindAddress: metricsAddr,
 		},
+		WebhookServer: ctrlwebhook.NewServer(ctrlwebhook.Options{
+			Port:    webhookPort,
+			CertDir: certOptions.CertDir,
+		}),
 		LeaderElection:   enableLeaderElection,
 		LeaderElectionID: "static.io",
 	})
@@ -58,8 +112,50 @@ func main() {
 		os.Exit(1)
 	}
 
+	if err = (&controller.StaticAPIReconciler{
+		Client: mgr.GetClient(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "StaticAPI")
+		os.Exit(1)
+	}
+
+	if enableWebhooks {
+		if err = (&webhook.StaticValidator{}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "Static")
+			os.Exit(1)
+		}
+
+		if err = (&webhook.StaticAPIValidator{
+			Client: mgr.GetClient(),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "StaticAPI")
+			os.Exit(1)
+		}
+	}
+
+	if caBundle != nil {
+		injector := &webhook.CAInjector{
+			Client:     mgr.GetClient(),
+			ConfigName: webhookConfig,
+			CABundle:   caBundle,
+		}
+		if err = injector.SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "CAInjector")
+			os.Exit(1)
+		}
+
+		if err = mgr.Add(&webhook.CertRenewer{
+			Client:   certClient,
+			Options:  certOptions,
+			Injector: injector,
+		}); err != nil {
+			setupLog.Error(err, "unable to add webhook certificate renewer")
+			os.Exit(1)
+		}
+	}
+
 	setupLog.Info("starting manager")
-	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
+	if err := mgr.Start(ctx); err != nil {
 		setupLog.Error(err, "problem running manager")
 		os.Exit(1)
 	}
@@ -1,7 +1,17 @@
 package main
 
-import "github.com/antonjah/static/internal/static"
+import (
+	"os"
+
+	"github.com/antonjah/static/internal/openapi"
+	"github.com/antonjah/static/internal/static"
+)
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "openapi" {
+		openapi.Run(os.Args[2:])
+		return
+	}
+
 	static.Run()
 }
@@ -0,0 +1,197 @@
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// CA is a certificate authority issuing certificates signed by its key
+type CA struct {
+	Certificate *x509.Certificate
+	Key         *ecdsa.PrivateKey
+
+	// CertificatePEM and KeyPEM are the PEM encoded certificate and key
+	CertificatePEM []byte
+	KeyPEM         []byte
+}
+
+// NewCA creates a self-signed CA valid for the given duration.
+func NewCA(commonName string, validFor time.Duration) (*CA, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate CA key: %w", err)
+	}
+
+	template, err := newTemplate(commonName, validFor)
+	if err != nil {
+		return nil, err
+	}
+	template.IsCA = true
+	template.BasicConstraintsValid = true
+	template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CA certificate: %w", err)
+	}
+	return newCA(der, key)
+}
+
+// LoadCA loads a CA from its PEM encoded certificate and key.
+func LoadCA(certificatePEM, keyPEM []byte) (*CA, error) {
+	block, _ := pem.Decode(certificatePEM)
+	if block == nil {
+		return nil, errors.New("no PEM encoded CA certificate found")
+	}
+	keyBlock, _ := pem.Decode(keyPEM)
+	if keyBlock == nil {
+		return nil, errors.New("no PEM encoded CA key found")
+	}
+	key, err := x509.ParseECPrivateKey(keyBlock.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CA key: %w", err)
+	}
+	return newCA(block.Bytes, key)
+}
+
+func newCA(der []byte, key *ecdsa.PrivateKey) (*CA, error) {
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CA certificate: %w", err)
+	}
+	keyPEM, err := encodeKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return &CA{
+		Certificate:    certificate,
+		Key:            key,
+		CertificatePEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		KeyPEM:         keyPEM,
+	}, nil
+}
+
+// IssueServer issues a server certificate for the given DNS names and IP addresses,
+// returning the PEM encoded certificate and key.
+func (ca *CA) IssueServer(commonName string, hosts []string, validFor time.Duration) ([]byte, []byte, error) {
+	template, err := newTemplate(commonName, validFor)
+	if err != nil {
+		return nil, nil, err
+	}
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	if err := addSubjectAltNames(template, hosts); err != nil {
+		return nil, nil, err
+	}
+	return ca.issue(template)
+}
+
+// IssueClient issues a client certificate with the given organizational units and subject
+// alternative names, which are DNS names, IP addresses, email addresses or URIs, returning
+// the PEM encoded certificate and key.
+func (ca *CA) IssueClient(commonName string, organizationalUnits, names []string, validFor time.Duration) ([]byte, []byte, error) {
+	template, err := newTemplate(commonName, validFor)
+	if err != nil {
+		return nil, nil, err
+	}
+	template.Subject.OrganizationalUnit = organizationalUnits
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	if err := addSubjectAltNames(template, names); err != nil {
+		return nil, nil, err
+	}
+	return ca.issue(template)
+}
+
+func addSubjectAltNames(template *x509.Certificate, names []string) error {
+	for _, name := range names {
+		switch {
+		case net.ParseIP(name) != nil:
+			template.IPAddresses = append(template.IPAddresses, net.ParseIP(name))
+		case strings.Contains(name, "://"):
+			uri, err := url.Parse(name)
+			if err != nil {
+				return fmt.Errorf("invalid URI %s: %w", name, err)
+			}
+			template.URIs = append(template.URIs, uri)
+		case strings.Contains(name, "@"):
+			template.EmailAddresses = append(template.EmailAddresses, name)
+		default:
+			template.DNSNames = append(template.DNSNames, name)
+		}
+	}
+	return nil
+}
+
+func (ca *CA) issue(template *x509.Certificate) ([]byte, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.Certificate, &key.PublicKey, ca.Key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create certificate: %w", err)
+	}
+	keyPEM, err := encodeKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), keyPEM, nil
+}
+
+// ValidFor reports whether the PEM encoded certificate is signed by ca, covers host and is
+// valid for at least the given duration.
+func (ca *CA) ValidFor(certificatePEM []byte, host string, remaining time.Duration) bool {
+	block, _ := pem.Decode(certificatePEM)
+	if block == nil {
+		return false
+	}
+	certificate, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return false
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Certificate)
+	_, err = certificate.Verify(x509.VerifyOptions{
+		DNSName:     host,
+		Roots:       roots,
+		CurrentTime: time.Now().Add(remaining),
+		KeyUsages:   []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	return err == nil
+}
+
+func newTemplate(commonName string, validFor time.Duration) (*x509.Certificate, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate serial number: %w", err)
+	}
+	now := time.Now()
+	return &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName},
+		// allow for clock skew between the issuer and its clients
+		NotBefore: now.Add(-time.Hour),
+		NotAfter:  now.Add(validFor),
+	}, nil
+}
+
+func encodeKey(key *ecdsa.PrivateKey) ([]byte, error) {
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode key: %w", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), nil
+}
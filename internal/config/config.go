@@ -14,20 +14,48 @@ const staticapisFileName = "staticapis.yaml"
 
 // Config holds the parsed application configuration
 type Config struct {
-	Hostname       string `env:"HOSTNAME" envDefault:"127.0.0.1"`
-	Port           string `env:"PORT" envDefault:"8080"`
-	LogLevel       string `env:"LOG_LEVEL" envDefault:"info"`
-	LogPretty      bool   `env:"LOG_PRETTY" envDefault:"false"`
-	TLS            bool   `env:"TLS_ENABLED" envDefault:"false"`
-	Certificate    string `env:"TLS_CERTIFICATE" envDefault:""`
-	Key            string `env:"TLS_KEY" envDefault:""`
-	CA             string `env:"TLS_CA" envDefault:""`
-	VerifyClient   bool   `env:"TLS_VERIFY_CLIENT" envDefault:"false"`
-	StaticAPIsPath string `env:"STATICAPIS_PATH" envDefault:""`
-	Namespace      string `env:"NAMESPACE" envDefault:""`
-	InCluster      bool   `env:"IN_CLUSTER" envDefault:"false"`
+	Hostname       string   `env:"HOSTNAME" envDefault:"127.0.0.1"`
+	Port           string   `env:"PORT" envDefault:"8080"`
+	LogLevel       string   `env:"LOG_LEVEL" envDefault:"info"`
+	LogPretty      bool     `env:"LOG_PRETTY" envDefault:"false"`
+	MetricsPort    string   `env:"METRICS_PORT" envDefault:"9090"`
+	TLS            bool     `env:"TLS_ENABLED" envDefault:"false"`
+	Certificates   []string `env:"TLS_CERTIFICATE" envSeparator:","`
+	Keys           []string `env:"TLS_KEY" envSeparator:","`
+	CA             string   `env:"TLS_CA" envDefault:""`
+	VerifyClient   bool     `env:"TLS_VERIFY_CLIENT" envDefault:"false"`
+	TLSHosts       []string `env:"TLS_HOSTS" envSeparator:","`
+	ClientIssuer   bool     `env:"TLS_CLIENT_ISSUER" envDefault:"false"`
+	StaticAPIsPath string   `env:"STATICAPIS_PATH" envDefault:""`
+	Namespace      string   `env:"NAMESPACE" envDefault:""`
+	InCluster      bool     `env:"IN_CLUSTER" envDefault:"false"`
+
+	APISelector   string   `env:"STATICAPI_SELECTOR" envDefault:""`
+	APINamespaces []string `env:"STATICAPI_NAMESPACES" envSeparator:","`
+
+	BodyLimit int64 `env:"REQUEST_BODY_LIMIT" envDefault:"10485760"`
+
+	JournalSize      int `env:"JOURNAL_SIZE" envDefault:"1000"`
+	JournalBodyLimit int `env:"JOURNAL_BODY_LIMIT" envDefault:"65536"`
+
+	UpstreamURL string            `env:"UPSTREAM_URL" envDefault:""`
+	Upstreams   map[string]string `env:"UPSTREAMS" envKeyValSeparator:"="`
+
+	ValidationOpenAPI string `env:"VALIDATION_OPENAPI" envDefault:""`
+	ValidationStatus  int    `env:"VALIDATION_STATUS" envDefault:"400"`
+
+	AccessLog       string `env:"ACCESS_LOG" envDefault:""`
+	AccessLogFormat string `env:"ACCESS_LOG_FORMAT" envDefault:"json"`
+
+	TracingEndpoint      string `env:"OTEL_EXPORTER_OTLP_ENDPOINT" envDefault:""`
+	TraceResponseHeaders bool   `env:"TRACE_RESPONSE_HEADERS" envDefault:"false"`
+
+	RecordUpstream string `env:"RECORD_UPSTREAM" envDefault:""`
+	RecordOutput   string `env:"RECORD_OUTPUT" envDefault:"recordings.yaml"`
+	RecordFormat   string `env:"RECORD_FORMAT" envDefault:"yaml"`
 
 	Address        string
+	MetricsAddress string
 	StaticAPIsFile string
 }
 
@@ -40,6 +68,9 @@ func New() Config {
 	}
 
 	config.Address = fmt.Sprintf("%s:%s", config.Hostname, config.Port)
+	if config.MetricsPort != "0" {
+		config.MetricsAddress = fmt.Sprintf("%s:%s", config.Hostname, config.MetricsPort)
+	}
 
 	// Auto-detect Kubernetes environment if not explicitly set
 	if !config.InCluster && config.Namespace == "" {
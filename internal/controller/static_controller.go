@@ -3,8 +3,10 @@ package controller
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 
+	"golang.org/x/exp/slices"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -17,6 +19,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
+	"github.com/antonjah/static/internal/static"
 	staticv1alpha1 "github.com/antonjah/static/pkg/apis/static/v1alpha1"
 )
 
@@ -59,6 +62,48 @@ func (r *StaticReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 	return ctrl.Result{}, r.updateStatus(ctx, &static)
 }
 
+// ValidateStatic checks the parts of a Static the deployment cannot be built from,
+// for both the reconciler and the validating webhook.
+func ValidateStatic(static *staticv1alpha1.Static) error {
+	if tls := static.Spec.TLS; tls != nil && tls.Enabled {
+		// cannot specify both SecretName and file paths
+		if tls.SecretName != "" && (tls.Certificate != "" || tls.Key != "" || tls.CA != "") {
+			return fmt.Errorf("cannot specify both secretName and file paths (certificate/key/ca) in TLS config")
+		}
+		if len(tls.SNISecretNames) > 0 && tls.SecretName == "" {
+			return fmt.Errorf("sniSecretNames require secretName in TLS config")
+		}
+		// generated certificates are not read from anywhere
+		if tls.AutoGenerate && (tls.SecretName != "" || tls.Certificate != "" || tls.Key != "" || tls.CA != "") {
+			return fmt.Errorf("cannot specify autoGenerate with secretName or file paths (certificate/key/ca) in TLS config")
+		}
+		if tls.ClientIssuer && !tls.AutoGenerate {
+			return fmt.Errorf("clientIssuer requires autoGenerate in TLS config")
+		}
+	}
+
+	if accessLog := static.Spec.AccessLog; accessLog != nil && accessLog.Format != "" {
+		if err := validateAccessLogFormat(accessLog.Format); err != nil {
+			return err
+		}
+	}
+
+	if static.Spec.APISelector != nil {
+		if _, err := metav1.LabelSelectorAsSelector(static.Spec.APISelector); err != nil {
+			return fmt.Errorf("invalid apiSelector: %w", err)
+		}
+	}
+	return nil
+}
+
+// validateAccessLogFormat checks the static service supports the access log format.
+func validateAccessLogFormat(format string) error {
+	if !slices.Contains(static.AccessLogFormats, format) {
+		return fmt.Errorf("unsupported accessLog format %q, expected one of %s", format, strings.Join(static.AccessLogFormats, ", "))
+	}
+	return nil
+}
+
 func (r *StaticReconciler) reconcileDeployment(ctx context.Context, static *staticv1alpha1.Static) error {
 	logger := log.FromContext(ctx)
 
@@ -90,6 +135,10 @@ func (r *StaticReconciler) reconcileDeployment(ctx context.Context, static *stat
 		"app.kubernetes.io/managed-by": "static-operator",
 	}
 
+	if err := ValidateStatic(static); err != nil {
+		return err
+	}
+
 	deployment := &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      static.Name,
@@ -121,6 +170,33 @@ func (r *StaticReconciler) reconcileDeployment(ctx context.Context, static *stat
 								ContainerPort: 8080,
 								Protocol:      corev1.ProtocolTCP,
 							},
+							{
+								Name:          "metrics",
+								ContainerPort: 9090,
+								Protocol:      corev1.ProtocolTCP,
+							},
+						},
+						// probe the metrics port, which is served without TLS client verification
+						LivenessProbe: &corev1.Probe{
+							ProbeHandler: corev1.ProbeHandler{
+								HTTPGet: &corev1.HTTPGetAction{
+									Path: "/_static/healthz",
+									Port: intstr.FromString("metrics"),
+								},
+							},
+							PeriodSeconds:    10,
+							FailureThreshold: 3,
+						},
+						// ready once the StaticAPIs are loaded, so rollouts wait until they are served
+						ReadinessProbe: &corev1.Probe{
+							ProbeHandler: corev1.ProbeHandler{
+								HTTPGet: &corev1.HTTPGetAction{
+									Path: "/_static/readyz",
+									Port: intstr.FromString("metrics"),
+								},
+							},
+							PeriodSeconds:    5,
+							FailureThreshold: 3,
 						},
 						Env: []corev1.EnvVar{
 							{
@@ -146,21 +222,62 @@ func (r *StaticReconciler) reconcileDeployment(ctx context.Context, static *stat
 		}
 
 		if static.Spec.TLS != nil && static.Spec.TLS.Enabled {
-			// Validate: cannot specify both SecretName and file paths
-			if static.Spec.TLS.SecretName != "" && (static.Spec.TLS.Certificate != "" || static.Spec.TLS.Key != "" || static.Spec.TLS.CA != "") {
-				return fmt.Errorf("cannot specify both secretName and file paths (certificate/key/ca) in TLS config")
-			}
-
 			deployment.Spec.Template.Spec.Containers[0].Env = append(
 				deployment.Spec.Template.Spec.Containers[0].Env,
 				corev1.EnvVar{Name: "TLS_ENABLED", Value: "true"},
 			)
 
-			if static.Spec.TLS.SecretName != "" {
+			if static.Spec.TLS.AutoGenerate {
+				// issue the certificate for the names of the Service in front of the pods
+				hosts := []string{
+					static.Name,
+					fmt.Sprintf("%s.%s", static.Name, static.Namespace),
+					fmt.Sprintf("%s.%s.svc", static.Name, static.Namespace),
+					fmt.Sprintf("%s.%s.svc.cluster.local", static.Name, static.Namespace),
+				}
+				deployment.Spec.Template.Spec.Containers[0].Env = append(
+					deployment.Spec.Template.Spec.Containers[0].Env,
+					corev1.EnvVar{Name: "TLS_HOSTS", Value: strings.Join(hosts, ",")},
+				)
+				if static.Spec.TLS.ClientIssuer {
+					deployment.Spec.Template.Spec.Containers[0].Env = append(
+						deployment.Spec.Template.Spec.Containers[0].Env,
+						corev1.EnvVar{Name: "TLS_CLIENT_ISSUER", Value: "true"},
+					)
+				}
+			} else if static.Spec.TLS.SecretName != "" {
+				// the certificates of the SNI Secrets are mounted next to the default one
+				certificates := []string{"/tls/tls.crt"}
+				keys := []string{"/tls/tls.key"}
+				for i, secretName := range static.Spec.TLS.SNISecretNames {
+					name := fmt.Sprintf("tls-sni-%d", i)
+					certificates = append(certificates, fmt.Sprintf("/tls-sni/%s/tls.crt", secretName))
+					keys = append(keys, fmt.Sprintf("/tls-sni/%s/tls.key", secretName))
+					deployment.Spec.Template.Spec.Containers[0].VolumeMounts = append(
+						deployment.Spec.Template.Spec.Containers[0].VolumeMounts,
+						corev1.VolumeMount{
+							Name:      name,
+							MountPath: "/tls-sni/" + secretName,
+							ReadOnly:  true,
+						},
+					)
+					deployment.Spec.Template.Spec.Volumes = append(
+						deployment.Spec.Template.Spec.Volumes,
+						corev1.Volume{
+							Name: name,
+							VolumeSource: corev1.VolumeSource{
+								Secret: &corev1.SecretVolumeSource{
+									SecretName: secretName,
+								},
+							},
+						},
+					)
+				}
+
 				deployment.Spec.Template.Spec.Containers[0].Env = append(
 					deployment.Spec.Template.Spec.Containers[0].Env,
-					corev1.EnvVar{Name: "TLS_CERTIFICATE", Value: "/tls/tls.crt"},
-					corev1.EnvVar{Name: "TLS_KEY", Value: "/tls/tls.key"},
+					corev1.EnvVar{Name: "TLS_CERTIFICATE", Value: strings.Join(certificates, ",")},
+					corev1.EnvVar{Name: "TLS_KEY", Value: strings.Join(keys, ",")},
 				)
 				deployment.Spec.Template.Spec.Containers[0].VolumeMounts = append(
 					deployment.Spec.Template.Spec.Containers[0].VolumeMounts,
@@ -217,6 +334,73 @@ func (r *StaticReconciler) reconcileDeployment(ctx context.Context, static *stat
 			}
 		}
 
+		if static.Spec.Tracing != nil {
+			if static.Spec.Tracing.Endpoint != "" {
+				deployment.Spec.Template.Spec.Containers[0].Env = append(
+					deployment.Spec.Template.Spec.Containers[0].Env,
+					corev1.EnvVar{Name: "OTEL_EXPORTER_OTLP_ENDPOINT", Value: static.Spec.Tracing.Endpoint},
+					corev1.EnvVar{Name: "OTEL_SERVICE_NAME", Value: static.Name},
+				)
+			}
+			if static.Spec.Tracing.ResponseHeaders {
+				deployment.Spec.Template.Spec.Containers[0].Env = append(
+					deployment.Spec.Template.Spec.Containers[0].Env,
+					corev1.EnvVar{Name: "TRACE_RESPONSE_HEADERS", Value: "true"},
+				)
+			}
+		}
+
+		if static.Spec.AccessLog != nil {
+			format := "json"
+			if static.Spec.AccessLog.Format != "" {
+				format = static.Spec.AccessLog.Format
+			}
+			deployment.Spec.Template.Spec.Containers[0].Env = append(
+				deployment.Spec.Template.Spec.Containers[0].Env,
+				corev1.EnvVar{Name: "ACCESS_LOG", Value: "stdout"},
+				corev1.EnvVar{Name: "ACCESS_LOG_FORMAT", Value: format},
+			)
+		}
+
+		if static.Spec.APISelector != nil {
+			selector, err := metav1.LabelSelectorAsSelector(static.Spec.APISelector)
+			if err != nil {
+				return fmt.Errorf("invalid apiSelector: %w", err)
+			}
+			deployment.Spec.Template.Spec.Containers[0].Env = append(
+				deployment.Spec.Template.Spec.Containers[0].Env,
+				corev1.EnvVar{Name: "STATICAPI_SELECTOR", Value: selector.String()},
+			)
+		}
+
+		if len(static.Spec.Namespaces) > 0 {
+			deployment.Spec.Template.Spec.Containers[0].Env = append(
+				deployment.Spec.Template.Spec.Containers[0].Env,
+				corev1.EnvVar{Name: "STATICAPI_NAMESPACES", Value: strings.Join(static.Spec.Namespaces, ",")},
+			)
+		}
+
+		if static.Spec.Upstream != nil {
+			if static.Spec.Upstream.URL != "" {
+				deployment.Spec.Template.Spec.Containers[0].Env = append(
+					deployment.Spec.Template.Spec.Containers[0].Env,
+					corev1.EnvVar{Name: "UPSTREAM_URL", Value: static.Spec.Upstream.URL},
+				)
+			}
+			if len(static.Spec.Upstream.Prefixes) > 0 {
+				// sort the prefixes so the pod template does not change between reconciles
+				upstreams := make([]string, 0, len(static.Spec.Upstream.Prefixes))
+				for prefix, url := range static.Spec.Upstream.Prefixes {
+					upstreams = append(upstreams, prefix+"="+url)
+				}
+				sort.Strings(upstreams)
+				deployment.Spec.Template.Spec.Containers[0].Env = append(
+					deployment.Spec.Template.Spec.Containers[0].Env,
+					corev1.EnvVar{Name: "UPSTREAMS", Value: strings.Join(upstreams, ",")},
+				)
+			}
+		}
+
 		if static.Spec.Resources != nil {
 			deployment.Spec.Template.Spec.Containers[0].Resources = *static.Spec.Resources
 		} else {
@@ -272,6 +456,13 @@ func (r *StaticReconciler) reconcileService(ctx context.Context, static *staticv
 				TargetPort: intstr.FromInt(8080),
 				Protocol:   corev1.ProtocolTCP,
 			},
+			// scraped by a ServiceMonitor selecting the port by name
+			{
+				Name:       "metrics",
+				Port:       9090,
+				TargetPort: intstr.FromString("metrics"),
+				Protocol:   corev1.ProtocolTCP,
+			},
 		}
 
 		return controllerutil.SetControllerReference(static, service, r.Scheme)
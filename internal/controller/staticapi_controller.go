@@ -0,0 +1,367 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang.org/x/exp/slices"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/antonjah/static/internal/static"
+	staticv1alpha1 "github.com/antonjah/static/pkg/apis/static/v1alpha1"
+)
+
+// StaticAPIReconciler reports in the status of StaticAPIs whether the static service accepts
+// them, and which Static instances serve them.
+type StaticAPIReconciler struct {
+	client.Client
+}
+
+// Condition reasons
+const (
+	ConditionReasonAccepted    = "Accepted"
+	ConditionReasonInvalid     = "Invalid"
+	ConditionReasonConflicting = "PathConflict"
+	ConditionReasonUnresolved  = "BodySourceUnresolved"
+)
+
+func (r *StaticAPIReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	var staticAPI staticv1alpha1.StaticAPI
+	if err := r.Get(ctx, req.NamespacedName, &staticAPI); err != nil {
+		if errors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		logger.Error(err, "unable to fetch StaticAPI")
+		return ctrl.Result{}, err
+	}
+
+	if !staticAPI.ObjectMeta.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	// Statics may serve StaticAPIs from other namespaces, so both are listed in all of them
+	var staticAPIs staticv1alpha1.StaticAPIList
+	if err := r.List(ctx, &staticAPIs); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	var statics staticv1alpha1.StaticList
+	if err := r.List(ctx, &statics); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	status := staticAPI.Status.DeepCopy()
+	status.ObservedGeneration = staticAPI.Generation
+	status.Methods = methods(&staticAPI)
+	var loaded [][]staticv1alpha1.StaticAPI
+	status.ServedBy, loaded = LoadedWith(&staticAPI, statics.Items, staticAPIs.Items)
+
+	// the static service skips StaticAPIs that are invalid or whose bodies cannot be read
+	var conflict string
+	var unresolved error
+	invalid := static.ValidateStaticAPI(staticAPI)
+	if invalid == nil {
+		unresolved = static.ResolveBodySources(ctx, r, staticAPI)
+	}
+	if invalid == nil && unresolved == nil {
+		for _, served := range loaded {
+			if conflict = r.conflict(ctx, &staticAPI, served); conflict != "" {
+				break
+			}
+		}
+	}
+	switch {
+	case invalid != nil:
+		status.Message = fmt.Sprintf("invalid: %v", invalid)
+		setConditions(status, staticAPI.Generation, ConditionReasonInvalid, status.Message, true, false)
+	case unresolved != nil:
+		status.Message = fmt.Sprintf("unresolved body: %v", unresolved)
+		setConditions(status, staticAPI.Generation, ConditionReasonUnresolved, status.Message, true, false)
+	case conflict != "":
+		status.Message = fmt.Sprintf("path %s conflicts with StaticAPI %s", static.Pattern(staticAPI.Spec.Host, staticAPI.Spec.Path), conflict)
+		setConditions(status, staticAPI.Generation, ConditionReasonConflicting, status.Message, false, true)
+	case len(status.ServedBy) == 0:
+		status.Message = "accepted, but no Static serves it"
+		setConditions(status, staticAPI.Generation, ConditionReasonAccepted, status.Message, false, false)
+	default:
+		status.Message = fmt.Sprintf("served at %s", static.Pattern(staticAPI.Spec.Host, staticAPI.Spec.Path))
+		setConditions(status, staticAPI.Generation, ConditionReasonAccepted, status.Message, false, false)
+	}
+	status.Ready = invalid == nil && unresolved == nil && conflict == "" && len(status.ServedBy) > 0
+
+	if equality.Semantic.DeepEqual(status, &staticAPI.Status) {
+		return ctrl.Result{}, nil
+	}
+
+	staticAPI.Status = *status
+	if err := r.Status().Update(ctx, &staticAPI); err != nil {
+		if errors.IsConflict(err) {
+			return ctrl.Result{Requeue: true}, nil
+		}
+		logger.Error(err, "failed to update StaticAPI status")
+		return ctrl.Result{}, err
+	}
+
+	logger.Info("StaticAPI status updated", "name", staticAPI.Name, "ready", status.Ready)
+	return ctrl.Result{}, nil
+}
+
+// conflict returns the name of the StaticAPI the path of staticAPI conflicts with when it
+// is loaded with staticAPIs, or "" if there is none. Like the static service, it loads
+// StaticAPIs in order of namespace and name, so the first of two conflicting ones is
+// served, and skips the ones that are invalid or whose bodies cannot be read.
+func (r *StaticAPIReconciler) conflict(ctx context.Context, staticAPI *staticv1alpha1.StaticAPI, staticAPIs []staticv1alpha1.StaticAPI) string {
+	sort.Slice(staticAPIs, func(i, j int) bool {
+		if staticAPIs[i].Namespace != staticAPIs[j].Namespace {
+			return staticAPIs[i].Namespace < staticAPIs[j].Namespace
+		}
+		return staticAPIs[i].Name < staticAPIs[j].Name
+	})
+
+	pattern := static.Pattern(staticAPI.Spec.Host, staticAPI.Spec.Path)
+	accepted := static.NewPatternSet()
+	for _, other := range staticAPIs {
+		if other.Namespace == staticAPI.Namespace && other.Name == staticAPI.Name {
+			break
+		}
+		otherPattern := static.Pattern(other.Spec.Host, other.Spec.Path)
+		if static.ValidateStaticAPI(other) != nil || static.ResolveBodySources(ctx, r, other) != nil || !accepted.Add(otherPattern) {
+			continue
+		}
+		// other is served, so staticAPI is not if their patterns conflict
+		if static.ConflictingPath(pattern, []string{otherPattern}) >= 0 {
+			if other.Namespace != staticAPI.Namespace {
+				return other.Namespace + "/" + other.Name
+			}
+			return other.Name
+		}
+	}
+	return ""
+}
+
+// LoadedWith returns the Static instances serving staticAPI and, for each of them, the
+// StaticAPIs it loads. If no Static serves staticAPI, the StaticAPIs in its namespace are
+// returned instead, as those a Static created in the namespace would load.
+func LoadedWith(staticAPI *staticv1alpha1.StaticAPI, statics []staticv1alpha1.Static, staticAPIs []staticv1alpha1.StaticAPI) ([]string, [][]staticv1alpha1.StaticAPI) {
+	var servedBy []string
+	var loaded [][]staticv1alpha1.StaticAPI
+	for _, s := range statics {
+		if !serves(&s, staticAPI) {
+			continue
+		}
+		name := s.Name
+		if s.Namespace != staticAPI.Namespace {
+			name = s.Namespace + "/" + s.Name
+		}
+		servedBy = append(servedBy, name)
+
+		var served []staticv1alpha1.StaticAPI
+		for _, other := range staticAPIs {
+			if serves(&s, &other) {
+				served = append(served, other)
+			}
+		}
+		loaded = append(loaded, served)
+	}
+	sort.Strings(servedBy)
+
+	if len(loaded) == 0 {
+		var served []staticv1alpha1.StaticAPI
+		for _, other := range staticAPIs {
+			if other.Namespace == staticAPI.Namespace {
+				served = append(served, other)
+			}
+		}
+		loaded = append(loaded, served)
+	}
+	return servedBy, loaded
+}
+
+// serves reports whether static loads staticAPI: whether it is in the namespace of static
+// or one of its extra namespaces, and matches its apiSelector.
+func serves(static *staticv1alpha1.Static, staticAPI *staticv1alpha1.StaticAPI) bool {
+	if staticAPI.Namespace != static.Namespace && !slices.Contains(static.Spec.Namespaces, staticAPI.Namespace) {
+		return false
+	}
+	if static.Spec.APISelector == nil {
+		return true
+	}
+	selector, err := metav1.LabelSelectorAsSelector(static.Spec.APISelector)
+	if err != nil {
+		// the Static reconciler reports the invalid selector, and the deployment is not updated
+		return false
+	}
+	return selector.Matches(labels.Set(staticAPI.Labels))
+}
+
+// setConditions sets the Accepted, Invalid and Conflicting conditions of status.
+func setConditions(status *staticv1alpha1.StaticAPIStatus, generation int64, reason, message string, invalid, conflicting bool) {
+	conditions := []struct {
+		conditionType string
+		value         bool
+	}{
+		{staticv1alpha1.ConditionAccepted, !invalid && !conflicting},
+		{staticv1alpha1.ConditionInvalid, invalid},
+		{staticv1alpha1.ConditionConflicting, conflicting},
+	}
+	for _, condition := range conditions {
+		conditionStatus := metav1.ConditionFalse
+		if condition.value {
+			conditionStatus = metav1.ConditionTrue
+		}
+		meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+			Type:               condition.conditionType,
+			Status:             conditionStatus,
+			ObservedGeneration: generation,
+			Reason:             reason,
+			Message:            message,
+		})
+	}
+}
+
+// methods returns the methods of staticAPI, e.g. "GET,POST".
+func methods(staticAPI *staticv1alpha1.StaticAPI) string {
+	names := make([]string, 0, len(staticAPI.Spec.Methods))
+	for _, method := range staticAPI.Spec.Methods {
+		names = append(names, strings.ToUpper(method.Method))
+	}
+	return strings.Join(names, ",")
+}
+
+// overlappingStaticAPIs enqueues the StaticAPIs whose path overlaps the path of a changed
+// StaticAPI, including itself, in all namespaces, since only those can conflict with it.
+func (r *StaticAPIReconciler) overlappingStaticAPIs(ctx context.Context, obj client.Object) []reconcile.Request {
+	staticAPI, ok := obj.(*staticv1alpha1.StaticAPI)
+	if !ok {
+		return nil
+	}
+
+	var staticAPIs staticv1alpha1.StaticAPIList
+	if err := r.List(ctx, &staticAPIs); err != nil {
+		log.FromContext(ctx).Error(err, "unable to list StaticAPIs")
+		return nil
+	}
+	return overlapping(staticAPIs.Items, staticAPI)
+}
+
+// servedStaticAPIs enqueues the StaticAPIs in the namespaces a changed Static serves.
+func (r *StaticAPIReconciler) servedStaticAPIs(ctx context.Context, obj client.Object) []reconcile.Request {
+	s, ok := obj.(*staticv1alpha1.Static)
+	if !ok {
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, namespace := range append([]string{s.Namespace}, s.Spec.Namespaces...) {
+		var staticAPIs staticv1alpha1.StaticAPIList
+		if err := r.List(ctx, &staticAPIs, client.InNamespace(namespace)); err != nil {
+			log.FromContext(ctx).Error(err, "unable to list StaticAPIs", "namespace", namespace)
+			continue
+		}
+		for _, staticAPI := range staticAPIs.Items {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: staticAPI.Namespace, Name: staticAPI.Name},
+			})
+		}
+	}
+	return requests
+}
+
+// referencingStaticAPIs enqueues the StaticAPIs reading bodies from a changed ConfigMap or
+// Secret, and the StaticAPIs overlapping them, as those are served when they are skipped.
+func (r *StaticAPIReconciler) referencingStaticAPIs(ctx context.Context, obj client.Object) []reconcile.Request {
+	_, secret := obj.(*corev1.Secret)
+
+	var staticAPIs staticv1alpha1.StaticAPIList
+	if err := r.List(ctx, &staticAPIs); err != nil {
+		log.FromContext(ctx).Error(err, "unable to list StaticAPIs")
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, staticAPI := range staticAPIs.Items {
+		if staticAPI.Namespace == obj.GetNamespace() && references(&staticAPI, secret, obj.GetName()) {
+			requests = append(requests, overlapping(staticAPIs.Items, &staticAPI)...)
+		}
+	}
+	return requests
+}
+
+// overlapping returns requests for staticAPI and the StaticAPIs whose pattern conflicts with
+// it, directly or through other conflicting StaticAPIs, since whether one of them is
+// served decides whether the next one is.
+func overlapping(staticAPIs []staticv1alpha1.StaticAPI, staticAPI *staticv1alpha1.StaticAPI) []reconcile.Request {
+	patterns := []string{static.Pattern(staticAPI.Spec.Host, staticAPI.Spec.Path)}
+	requests := []reconcile.Request{{
+		NamespacedName: types.NamespacedName{Namespace: staticAPI.Namespace, Name: staticAPI.Name},
+	}}
+	enqueued := map[types.NamespacedName]bool{requests[0].NamespacedName: true}
+	for i := 0; i < len(patterns); i++ {
+		for _, other := range staticAPIs {
+			name := types.NamespacedName{Namespace: other.Namespace, Name: other.Name}
+			otherPattern := static.Pattern(other.Spec.Host, other.Spec.Path)
+			if enqueued[name] || static.ConflictingPath(patterns[i], []string{otherPattern}) < 0 {
+				continue
+			}
+			enqueued[name] = true
+			patterns = append(patterns, otherPattern)
+			requests = append(requests, reconcile.Request{NamespacedName: name})
+		}
+	}
+	return requests
+}
+
+// references reports whether staticAPI reads a body from the named Secret, or ConfigMap if secret is false.
+func references(staticAPI *staticv1alpha1.StaticAPI, secret bool, name string) bool {
+	var sources []*staticv1alpha1.BodySource
+	for _, method := range staticAPI.Spec.Methods {
+		sources = append(sources, method.BodyFrom)
+		for _, response := range append(slices.Clone(method.Responses), method.Sequence...) {
+			sources = append(sources, response.BodyFrom)
+		}
+	}
+
+	for _, source := range sources {
+		switch {
+		case source == nil:
+		case secret && source.SecretKeyRef != nil && source.SecretKeyRef.Name == name:
+			return true
+		case !secret && source.ConfigMapKeyRef != nil && source.ConfigMapKeyRef.Name == name:
+			return true
+		}
+	}
+	return false
+}
+
+func (r *StaticAPIReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("staticapi").
+		Watches(&staticv1alpha1.StaticAPI{},
+			handler.EnqueueRequestsFromMapFunc(r.overlappingStaticAPIs),
+			// labels decide which Statics select a StaticAPI
+			builder.WithPredicates(predicate.Or(predicate.GenerationChangedPredicate{}, predicate.LabelChangedPredicate{}))).
+		Watches(&staticv1alpha1.Static{},
+			handler.EnqueueRequestsFromMapFunc(r.servedStaticAPIs),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		// the manager only caches the ConfigMaps and Secrets labeled as body sources
+		Watches(&corev1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(r.referencingStaticAPIs)).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.referencingStaticAPIs)).
+		Complete(r)
+}
@@ -0,0 +1,238 @@
+package controller
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	staticv1alpha1 "github.com/antonjah/static/pkg/apis/static/v1alpha1"
+)
+
+func newScheme(t *testing.T) *runtime.Scheme {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := staticv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	return scheme
+}
+
+func newStaticAPI(name, path string) *staticv1alpha1.StaticAPI {
+	return &staticv1alpha1.StaticAPI{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+		Spec: staticv1alpha1.StaticAPISpec{
+			Path:    path,
+			Methods: []staticv1alpha1.Method{{Method: "GET", StatusCode: 200}},
+		},
+	}
+}
+
+func TestStaticAPIReconcile(t *testing.T) {
+	// a is served, b conflicts with it, c reads its body from a missing ConfigMap key,
+	// so d is served although its path conflicts with the path of c
+	a := newStaticAPI("a", "/users/{id}")
+	b := newStaticAPI("b", "/users/{name}")
+	c := newStaticAPI("c", "/orders/{id}")
+	c.Spec.Methods[0].BodyFrom = &staticv1alpha1.BodySource{ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{Name: "fixtures"}, Key: "orders.json",
+	}}
+	d := newStaticAPI("d", "/orders/{name}")
+	e := newStaticAPI("e", "/files/{rest...}")
+	e.Spec.Methods[0].BodyFrom = &staticv1alpha1.BodySource{ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{Name: "fixtures"}, Key: "files.json",
+	}}
+	static := &staticv1alpha1.Static{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "static"}}
+	fixtures := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "fixtures",
+			Labels:    map[string]string{staticv1alpha1.BodySourceLabel: "true"},
+		},
+		Data: map[string]string{"files.json": "[]"},
+	}
+
+	k8sClient := fake.NewClientBuilder().
+		WithScheme(newScheme(t)).
+		WithObjects(a, b, c, d, e, static, fixtures).
+		WithStatusSubresource(&staticv1alpha1.StaticAPI{}).
+		Build()
+	r := &StaticAPIReconciler{Client: k8sClient}
+
+	tests := []struct {
+		name       string
+		wantReady  bool
+		wantReason string
+	}{
+		{name: "a", wantReady: true, wantReason: ConditionReasonAccepted},
+		{name: "b", wantReason: ConditionReasonConflicting},
+		{name: "c", wantReason: ConditionReasonUnresolved},
+		{name: "d", wantReady: true, wantReason: ConditionReasonAccepted},
+		{name: "e", wantReady: true, wantReason: ConditionReasonAccepted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := types.NamespacedName{Namespace: "default", Name: tt.name}
+			if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key}); err != nil {
+				t.Fatalf("Reconcile: %v", err)
+			}
+
+			var staticAPI staticv1alpha1.StaticAPI
+			if err := k8sClient.Get(context.Background(), key, &staticAPI); err != nil {
+				t.Fatal(err)
+			}
+			if staticAPI.Status.Ready != tt.wantReady {
+				t.Errorf("ready = %v, want %v (%s)", staticAPI.Status.Ready, tt.wantReady, staticAPI.Status.Message)
+			}
+			accepted := meta.FindStatusCondition(staticAPI.Status.Conditions, staticv1alpha1.ConditionAccepted)
+			if accepted == nil || accepted.Reason != tt.wantReason {
+				t.Errorf("Accepted condition = %+v, want reason %s", accepted, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestStaticAPIEnqueue(t *testing.T) {
+	// b conflicts with a and c, which do not conflict with each other
+	a := newStaticAPI("a", "/users/{id}")
+	b := newStaticAPI("b", "/{kind}/1")
+	c := newStaticAPI("c", "/orders/{id}")
+	d := newStaticAPI("d", "/products")
+	e := newStaticAPI("e", "/files")
+	e.Spec.Methods[0].BodyFrom = &staticv1alpha1.BodySource{SecretKeyRef: &corev1.SecretKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{Name: "fixtures"}, Key: "files.json",
+	}}
+	other := &staticv1alpha1.StaticAPI{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "other", Name: "users"},
+		Spec:       a.Spec,
+	}
+	static := &staticv1alpha1.Static{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "static"},
+	}
+
+	r := &StaticAPIReconciler{Client: fake.NewClientBuilder().
+		WithScheme(newScheme(t)).
+		WithObjects(a, b, c, d, e, other).
+		Build()}
+
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "fixtures"}}
+	configMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "fixtures"}}
+
+	tests := []struct {
+		name string
+		mapF func(context.Context, client.Object) []reconcile.Request
+		obj  client.Object
+		want []string
+	}{
+		{name: "overlapping through other StaticAPIs", mapF: r.overlappingStaticAPIs, obj: a, want: []string{"default/a", "default/b", "default/c", "other/users"}},
+		{name: "no overlap", mapF: r.overlappingStaticAPIs, obj: d, want: []string{"default/d"}},
+		{name: "namespace of a Static", mapF: r.servedStaticAPIs, obj: static, want: []string{"default/a", "default/b", "default/c", "default/d", "default/e"}},
+		{name: "referenced Secret", mapF: r.referencingStaticAPIs, obj: secret, want: []string{"default/e"}},
+		{name: "unreferenced ConfigMap", mapF: r.referencingStaticAPIs, obj: configMap},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := map[string]bool{}
+			for _, req := range tt.mapF(context.Background(), tt.obj) {
+				got[req.String()] = true
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("enqueued %v, want %v", got, tt.want)
+			}
+			for _, name := range tt.want {
+				if !got[name] {
+					t.Errorf("enqueued %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadedWith(t *testing.T) {
+	orders := newStaticAPI("orders", "/orders")
+	orders.Labels = map[string]string{"team": "payments"}
+	search := newStaticAPI("search", "/search")
+	search.Labels = map[string]string{"team": "search"}
+	invoices := newStaticAPI("invoices", "/invoices")
+	invoices.Namespace = "team-a"
+	invoices.Labels = map[string]string{"team": "payments"}
+	refunds := newStaticAPI("refunds", "/refunds")
+	refunds.Namespace = "team-b"
+	staticAPIs := []staticv1alpha1.StaticAPI{*orders, *search, *invoices, *refunds}
+
+	newStatic := func(name string, namespaces []string, selector *metav1.LabelSelector) staticv1alpha1.Static {
+		return staticv1alpha1.Static{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+			Spec:       staticv1alpha1.StaticSpec{Namespaces: namespaces, APISelector: selector},
+		}
+	}
+	all := newStatic("all", nil, nil)
+	payments := newStatic("payments", []string{"team-a"}, &metav1.LabelSelector{MatchLabels: map[string]string{"team": "payments"}})
+	invalid := newStatic("invalid", nil, &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "team", Operator: "Unknown"}}})
+	statics := []staticv1alpha1.Static{all, payments, invalid}
+
+	tests := []struct {
+		name         string
+		staticAPI    *staticv1alpha1.StaticAPI
+		wantServedBy []string
+		wantLoaded   [][]string
+	}{
+		{
+			name:         "namespace and selector",
+			staticAPI:    orders,
+			wantServedBy: []string{"all", "payments"},
+			wantLoaded:   [][]string{{"orders", "search"}, {"orders", "invoices"}},
+		},
+		{
+			name:         "not selected",
+			staticAPI:    search,
+			wantServedBy: []string{"all"},
+			wantLoaded:   [][]string{{"orders", "search"}},
+		},
+		{
+			name:         "extra namespace",
+			staticAPI:    invoices,
+			wantServedBy: []string{"default/payments"},
+			wantLoaded:   [][]string{{"orders", "invoices"}},
+		},
+		{
+			name:       "not served",
+			staticAPI:  refunds,
+			wantLoaded: [][]string{{"refunds"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			servedBy, loaded := LoadedWith(tt.staticAPI, statics, staticAPIs)
+			if !reflect.DeepEqual(servedBy, tt.wantServedBy) {
+				t.Errorf("servedBy = %v, want %v", servedBy, tt.wantServedBy)
+			}
+			var gotLoaded [][]string
+			for _, served := range loaded {
+				var names []string
+				for _, staticAPI := range served {
+					names = append(names, staticAPI.Name)
+				}
+				gotLoaded = append(gotLoaded, names)
+			}
+			if !reflect.DeepEqual(gotLoaded, tt.wantLoaded) {
+				t.Errorf("loaded = %v, want %v", gotLoaded, tt.wantLoaded)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package openapi
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/antonjah/static/internal/openapi/spec"
+	"github.com/antonjah/static/internal/static"
+)
+
+// Run implements the openapi subcommand, which writes StaticAPIs generated from an
+// OpenAPI document to a file or stdout:
+//
+//	static openapi [-format yaml|crd] [-output file] <spec|->
+func Run(args []string) {
+	flags := flag.NewFlagSet("openapi", flag.ExitOnError)
+	format := flags.String("format", static.FormatYAML, "Output format: yaml (staticapis.yaml) or crd (StaticAPI manifests)")
+	output := flags.String("output", "", "Output file (default: stdout)")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: static openapi [flags] <spec|->\n\nGenerates StaticAPIs from an OpenAPI 3 document.\n\n")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	if err := generate(flags.Arg(0), *output, *format); err != nil {
+		fmt.Fprintf(os.Stderr, "openapi: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// generate converts the OpenAPI document at input ("-" for stdin) and writes the result to output.
+func generate(input, output, format string) error {
+	if format != static.FormatYAML && format != static.FormatCRD {
+		return fmt.Errorf("invalid format %q, must be %s or %s", format, static.FormatYAML, static.FormatCRD)
+	}
+
+	var data []byte
+	var err error
+	if input == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(input)
+	}
+	if err != nil {
+		return err
+	}
+
+	doc, err := spec.Parse(data)
+	if err != nil {
+		return err
+	}
+
+	staticAPIs, skipped := Convert(doc)
+	for _, err := range skipped {
+		fmt.Fprintf(os.Stderr, "openapi: skipping %v\n", err)
+	}
+
+	if output == "" {
+		return static.WriteStaticAPIs(os.Stdout, staticAPIs, format)
+	}
+
+	fh, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	if err := static.WriteStaticAPIs(fh, staticAPIs, format); err != nil {
+		_ = fh.Close()
+		return err
+	}
+	return fh.Close()
+}
@@ -0,0 +1,216 @@
+// Package openapi generates StaticAPIs from OpenAPI 3 specifications.
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/antonjah/static/internal/openapi/spec"
+	"github.com/antonjah/static/internal/static"
+)
+
+// preferHeader selects a documented response other than the success response,
+// e.g. "Prefer: code=404"
+const preferHeader = "Prefer"
+
+// Convert generates a StaticAPI for every path of an OpenAPI document. Each operation returns
+// its lowest documented 2xx response by default; the other documented responses are
+// returned when requested with a "Prefer: code=<status>" header. Bodies are taken from
+// the examples of the response, or generated from its schema.
+//
+// Paths that cannot be served, e.g. because a parameter does not span a whole path
+// segment, are skipped and returned as errors in skipped.
+func Convert(doc *spec.Document) (staticAPIs []static.StaticAPI, skipped []error) {
+	for _, path := range sortedKeys(doc.Paths) {
+		staticAPI := static.StaticAPI{Path: convertPath(path)}
+
+		operations := doc.Paths[path].Operations()
+		for _, method := range sortedKeys(operations) {
+			staticAPI.Methods = append(staticAPI.Methods, convertOperation(doc, method, operations[method]))
+		}
+
+		if len(staticAPI.Methods) == 0 {
+			continue
+		}
+		if err := staticAPI.Validate(); err != nil {
+			skipped = append(skipped, fmt.Errorf("path %s: %w", path, err))
+			continue
+		}
+		staticAPIs = append(staticAPIs, staticAPI)
+	}
+	return staticAPIs, skipped
+}
+
+// invalidParamChars matches characters not allowed in path parameter names
+var invalidParamChars = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// paramPattern matches the path parameters of an OpenAPI path
+var paramPattern = regexp.MustCompile(`\{([^}]*)\}`)
+
+// convertPath turns the parameters of an OpenAPI path into valid path template
+// names, e.g. "/users/{user-id}" becomes "/users/{user_id}".
+func convertPath(path string) string {
+	return paramPattern.ReplaceAllStringFunc(path, func(param string) string {
+		return "{" + invalidParamChars.ReplaceAllString(param[1:len(param)-1], "_") + "}"
+	})
+}
+
+// convertOperation converts an operation to a method configuration.
+func convertOperation(doc *spec.Document, method string, operation *spec.Operation) static.MethodConfig {
+	config := static.MethodConfig{Method: method, StatusCode: 200}
+	found := false
+	for _, code := range sortedKeys(operation.Responses) {
+		status, ok := statusCode(code)
+		if !ok {
+			continue
+		}
+
+		response := convertResponse(doc, status, operation.Responses[code])
+		if !found && status >= 200 && status < 300 {
+			found = true
+			config.StatusCode = response.StatusCode
+			config.Body = response.Body
+			config.Headers = response.Headers
+			continue
+		}
+
+		response.Match = static.MatchConfig{
+			Headers: map[string]static.ValueMatch{
+				preferHeader: {Regex: `\bcode=` + strconv.Itoa(status) + `\b`},
+			},
+		}
+		config.Responses = append(config.Responses, response)
+	}
+
+	// fall back to the default response if no success response is documented
+	if !found {
+		if response, ok := operation.Responses["default"]; ok {
+			defaultResponse := convertResponse(doc, 200, response)
+			config.Body = defaultResponse.Body
+			config.Headers = defaultResponse.Headers
+		}
+	}
+	return config
+}
+
+// statusCode parses a response status code such as "404" or a range such as "2XX".
+func statusCode(code string) (int, bool) {
+	if len(code) == 3 && strings.EqualFold(code[1:], "XX") && code[0] >= '1' && code[0] <= '5' {
+		return int(code[0]-'0') * 100, true
+	}
+	status, err := strconv.Atoi(code)
+	if err != nil || status < 100 || status > 599 {
+		return 0, false
+	}
+	return status, true
+}
+
+// convertResponse converts a response to a response variant with a sample body.
+func convertResponse(doc *spec.Document, status int, response *spec.Response) static.ResponseConfig {
+	config := static.ResponseConfig{StatusCode: status}
+
+	response = doc.ResolveResponse(response)
+	if response == nil {
+		return config
+	}
+
+	headers := map[string]string{}
+	for name, header := range response.Headers {
+		if strings.EqualFold(name, "Content-Type") {
+			continue
+		}
+		if value := headerValue(doc, header); value != "" {
+			headers[strings.ToLower(name)] = value
+		}
+	}
+
+	if contentType, mediaType := preferredContent(response.Content); mediaType != nil {
+		headers["content-type"] = contentType
+		config.Body = encodeBody(contentType, sampleBody(doc, mediaType))
+	}
+
+	if len(headers) > 0 {
+		config.Headers = headers
+	}
+	return config
+}
+
+// preferredContent returns the JSON content of a response if there is one, or else the first content type.
+func preferredContent(content map[string]*spec.MediaType) (string, *spec.MediaType) {
+	contentTypes := sortedKeys(content)
+	for _, contentType := range contentTypes {
+		if spec.IsJSON(contentType) {
+			return contentType, content[contentType]
+		}
+	}
+	if len(contentTypes) > 0 {
+		return contentTypes[0], content[contentTypes[0]]
+	}
+	return "", nil
+}
+
+// sampleBody returns the example of a media type, its first named example, or a value generated from its schema.
+func sampleBody(doc *spec.Document, mediaType *spec.MediaType) any {
+	if mediaType.Example != nil {
+		return mediaType.Example
+	}
+
+	for _, name := range sortedKeys(mediaType.Examples) {
+		if example := doc.ResolveExample(mediaType.Examples[name]); example != nil && example.Value != nil {
+			return example.Value
+		}
+	}
+
+	return doc.Sample(mediaType.Schema)
+}
+
+// headerValue returns the example of a header, or a value generated from its schema.
+func headerValue(doc *spec.Document, header *spec.Header) string {
+	header = doc.ResolveHeader(header)
+	if header == nil {
+		return ""
+	}
+
+	value := header.Example
+	if value == nil {
+		value = doc.Sample(header.Schema)
+	}
+	if value == nil {
+		return ""
+	}
+	return fmt.Sprint(value)
+}
+
+// encodeBody encodes a sample value as a response body for contentType. Strings are
+// used as-is for non-JSON content types, or if they hold a JSON object or array.
+func encodeBody(contentType string, value any) string {
+	if value == nil {
+		return ""
+	}
+	if s, ok := value.(string); ok {
+		trimmed := strings.TrimSpace(s)
+		encoded := (strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[")) && json.Valid([]byte(trimmed))
+		if !spec.IsJSON(contentType) || encoded {
+			return s
+		}
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+// sortedKeys returns the keys of m in order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
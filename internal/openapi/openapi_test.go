@@ -0,0 +1,247 @@
+package openapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/antonjah/static/internal/openapi/spec"
+	"github.com/antonjah/static/internal/static"
+)
+
+func TestConvertPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "/users", want: "/users"},
+		{path: "/users/{id}", want: "/users/{id}"},
+		{path: "/users/{user-id}/orders/{order.id}", want: "/users/{user_id}/orders/{order_id}"},
+		{path: "/files/{name}.json", want: "/files/{name}.json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := convertPath(tt.path); got != tt.want {
+				t.Errorf("convertPath(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatusCode(t *testing.T) {
+	tests := []struct {
+		code   string
+		want   int
+		wantOK bool
+	}{
+		{code: "200", want: 200, wantOK: true},
+		{code: "404", want: 404, wantOK: true},
+		{code: "2XX", want: 200, wantOK: true},
+		{code: "5xx", want: 500, wantOK: true},
+		{code: "6XX"},
+		{code: "default"},
+		{code: "600"},
+		{code: "99"},
+		{code: "abc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			got, ok := statusCode(tt.code)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("statusCode(%q) = %d, %v, want %d, %v", tt.code, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestEncodeBody(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		value       any
+		want        string
+	}{
+		{name: "no value", contentType: "application/json", value: nil, want: ""},
+		{name: "JSON object", contentType: "application/json", value: map[string]any{"id": 1}, want: `{"id":1}`},
+		{name: "JSON string", contentType: "application/json", value: "hello", want: `"hello"`},
+		{name: "encoded JSON", contentType: "application/problem+json", value: `{"title": "Not Found"}`, want: `{"title": "Not Found"}`},
+		{name: "invalid encoded JSON", contentType: "application/json", value: `{"title"`, want: `"{\"title\""`},
+		{name: "text", contentType: "text/plain; charset=utf-8", value: "hello", want: "hello"},
+		{name: "number as text", contentType: "text/plain", value: 42, want: "42"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := encodeBody(tt.contentType, tt.value); got != tt.want {
+				t.Errorf("encodeBody() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+const petstore = `openapi: 3.0.3
+paths:
+  /pets:
+    get:
+      responses:
+        "200":
+          description: example
+          content:
+            application/json:
+              example: [{"id": 1, "name": "rex"}]
+        "500":
+          $ref: "#/components/responses/Error"
+    post:
+      responses:
+        "201":
+          description: generated from the schema
+          headers:
+            Location:
+              schema: {type: string, format: uri}
+            X-Rate-Limit:
+              $ref: "#/components/headers/RateLimit"
+          content:
+            application/json:
+              schema: {$ref: "#/components/schemas/Pet"}
+  /pets/{pet-id}:
+    get:
+      responses:
+        2XX:
+          description: named example
+          content:
+            text/plain:
+              examples:
+                rex: {$ref: "#/components/examples/Rex"}
+        "404":
+          description: not found
+    delete:
+      responses:
+        default:
+          description: no success response documented
+          content:
+            application/json:
+              schema: {type: object, properties: {deleted: {type: boolean}}}
+  /files/{name}.json:
+    get:
+      responses:
+        "200":
+          description: parameter within a segment
+  /empty: {}
+components:
+  schemas:
+    Pet:
+      type: object
+      properties:
+        id: {type: integer}
+        name: {type: string, example: rex}
+        tags: {type: array, items: {type: string, enum: [good]}}
+        parent: {$ref: "#/components/schemas/Pet"}
+  responses:
+    Error:
+      description: error
+      content:
+        application/problem+json:
+          schema: {type: object, properties: {title: {type: string, default: Internal Server Error}}}
+  headers:
+    RateLimit:
+      schema: {type: integer}
+      example: 100
+  examples:
+    Rex: {value: rex}
+`
+
+func TestConvert(t *testing.T) {
+	doc, err := spec.Parse([]byte(petstore))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	staticAPIs, skipped := Convert(doc)
+
+	prefer := func(status string) static.MatchConfig {
+		return static.MatchConfig{Headers: map[string]static.ValueMatch{"Prefer": {Regex: `\bcode=` + status + `\b`}}}
+	}
+	want := []static.StaticAPI{
+		{Path: "/pets", Methods: []static.MethodConfig{
+			{
+				Method: "GET", StatusCode: 200,
+				Body:    `[{"id":1,"name":"rex"}]`,
+				Headers: map[string]string{"content-type": "application/json"},
+				Responses: []static.ResponseConfig{{
+					Match:      prefer("500"),
+					StatusCode: 500,
+					Body:       `{"title":"Internal Server Error"}`,
+					Headers:    map[string]string{"content-type": "application/problem+json"},
+				}},
+			},
+			{
+				Method: "POST", StatusCode: 201,
+				Body: `{"id":0,"name":"rex","tags":["good"]}`,
+				Headers: map[string]string{
+					"content-type": "application/json",
+					"location":     "https://example.com",
+					"x-rate-limit": "100",
+				},
+			},
+		}},
+		{Path: "/pets/{pet_id}", Methods: []static.MethodConfig{
+			{Method: "DELETE", StatusCode: 200, Body: `{"deleted":true}`, Headers: map[string]string{"content-type": "application/json"}},
+			{
+				Method: "GET", StatusCode: 200,
+				Body:      "rex",
+				Headers:   map[string]string{"content-type": "text/plain"},
+				Responses: []static.ResponseConfig{{Match: prefer("404"), StatusCode: 404}},
+			},
+		}},
+	}
+	if !reflect.DeepEqual(staticAPIs, want) {
+		t.Errorf("Convert() =\n%+v\nwant\n%+v", staticAPIs, want)
+	}
+
+	if len(skipped) != 1 || !strings.Contains(skipped[0].Error(), "path /files/{name}.json") {
+		t.Errorf("skipped = %v, want /files/{name}.json", skipped)
+	}
+}
+
+func TestConvertPrefer(t *testing.T) {
+	doc, err := spec.Parse([]byte(petstore))
+	if err != nil {
+		t.Fatal(err)
+	}
+	staticAPIs, _ := Convert(doc)
+	staticAPI := staticAPIs[1]
+	staticAPI.SetSupported()
+
+	mux := http.NewServeMux()
+	mux.Handle(staticAPI.Path, &staticAPI)
+
+	tests := []struct {
+		name       string
+		prefer     string
+		wantStatus int
+	}{
+		{name: "success response by default", wantStatus: 200},
+		{name: "documented response", prefer: "code=404", wantStatus: 404},
+		{name: "with other preferences", prefer: "return=minimal, code=404", wantStatus: 404},
+		{name: "undocumented response", prefer: "code=418", wantStatus: 200},
+		{name: "code prefix only", prefer: "code=4040", wantStatus: 200},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/pets/1", nil)
+			if tt.prefer != "" {
+				req.Header.Set("Prefer", tt.prefer)
+			}
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, req)
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
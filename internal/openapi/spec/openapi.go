@@ -0,0 +1,216 @@
+// Package spec reads OpenAPI 3 documents, generates sample values from their
+// schemas and validates requests against them.
+package spec
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Document is the part of an OpenAPI 3 document needed to generate and validate requests and responses
+type Document struct {
+	OpenAPI    string               `yaml:"openapi"`
+	Paths      map[string]*PathItem `yaml:"paths"`
+	Components Components           `yaml:"components"`
+
+	// templates maps path templates without parameter names to the paths above
+	templates map[string]string
+}
+
+// Components holds the reusable objects that can be referenced with $ref
+type Components struct {
+	Schemas   map[string]*Schema   `yaml:"schemas"`
+	Responses map[string]*Response `yaml:"responses"`
+	Examples  map[string]*Example  `yaml:"examples"`
+	Headers   map[string]*Header   `yaml:"headers"`
+
+	Parameters    map[string]*Parameter   `yaml:"parameters"`
+	RequestBodies map[string]*RequestBody `yaml:"requestBodies"`
+}
+
+// PathItem holds the operations of a path
+type PathItem struct {
+	Get     *Operation `yaml:"get"`
+	Put     *Operation `yaml:"put"`
+	Post    *Operation `yaml:"post"`
+	Delete  *Operation `yaml:"delete"`
+	Options *Operation `yaml:"options"`
+	Head    *Operation `yaml:"head"`
+	Patch   *Operation `yaml:"patch"`
+	Trace   *Operation `yaml:"trace"`
+
+	Parameters []*Parameter `yaml:"parameters"`
+}
+
+// Operations returns the operations of the path item keyed by HTTP method.
+func (p *PathItem) Operations() map[string]*Operation {
+	operations := map[string]*Operation{}
+	for method, operation := range map[string]*Operation{
+		"GET":     p.Get,
+		"PUT":     p.Put,
+		"POST":    p.Post,
+		"DELETE":  p.Delete,
+		"OPTIONS": p.Options,
+		"HEAD":    p.Head,
+		"PATCH":   p.Patch,
+		"TRACE":   p.Trace,
+	} {
+		if operation != nil {
+			operations[method] = operation
+		}
+	}
+	return operations
+}
+
+// Operation is a single API operation on a path
+type Operation struct {
+	OperationID string               `yaml:"operationId"`
+	Parameters  []*Parameter         `yaml:"parameters"`
+	RequestBody *RequestBody         `yaml:"requestBody"`
+	Responses   map[string]*Response `yaml:"responses"`
+}
+
+// Parameter describes a path, query, header or cookie parameter of an operation
+type Parameter struct {
+	Ref      string  `yaml:"$ref"`
+	Name     string  `yaml:"name"`
+	In       string  `yaml:"in"`
+	Required bool    `yaml:"required"`
+	Schema   *Schema `yaml:"schema"`
+}
+
+// RequestBody describes the body of a request
+type RequestBody struct {
+	Ref      string                `yaml:"$ref"`
+	Required bool                  `yaml:"required"`
+	Content  map[string]*MediaType `yaml:"content"`
+}
+
+// Response describes a response of an operation
+type Response struct {
+	Ref     string                `yaml:"$ref"`
+	Headers map[string]*Header    `yaml:"headers"`
+	Content map[string]*MediaType `yaml:"content"`
+}
+
+// MediaType describes the body of a response for a content type
+type MediaType struct {
+	Schema   *Schema             `yaml:"schema"`
+	Example  any                 `yaml:"example"`
+	Examples map[string]*Example `yaml:"examples"`
+}
+
+// Example is a named example value
+type Example struct {
+	Ref   string `yaml:"$ref"`
+	Value any    `yaml:"value"`
+}
+
+// Header describes a response header
+type Header struct {
+	Ref     string  `yaml:"$ref"`
+	Schema  *Schema `yaml:"schema"`
+	Example any     `yaml:"example"`
+}
+
+// Schema is the subset of a JSON schema used to generate sample values and validate requests
+type Schema struct {
+	Ref        string             `yaml:"$ref,omitempty" json:"$ref,omitempty"`
+	Type       any                `yaml:"type,omitempty" json:"type,omitempty"` // a string, or a list of strings in OpenAPI 3.1
+	Format     string             `yaml:"format,omitempty" json:"format,omitempty"`
+	Nullable   bool               `yaml:"nullable,omitempty" json:"nullable,omitempty"`
+	Properties map[string]*Schema `yaml:"properties,omitempty" json:"properties,omitempty"`
+	Required   []string           `yaml:"required,omitempty" json:"required,omitempty"`
+	Items      *Schema            `yaml:"items,omitempty" json:"items,omitempty"`
+	Example    any                `yaml:"example,omitempty" json:"example,omitempty"`
+	Examples   []any              `yaml:"examples,omitempty" json:"examples,omitempty"`
+	Default    any                `yaml:"default,omitempty" json:"default,omitempty"`
+	Enum       []any              `yaml:"enum,omitempty" json:"enum,omitempty"`
+	AllOf      []*Schema          `yaml:"allOf,omitempty" json:"allOf,omitempty"`
+	OneOf      []*Schema          `yaml:"oneOf,omitempty" json:"oneOf,omitempty"`
+	AnyOf      []*Schema          `yaml:"anyOf,omitempty" json:"anyOf,omitempty"`
+
+	AdditionalProperties *AdditionalProperties `yaml:"additionalProperties,omitempty" json:"additionalProperties,omitempty"`
+
+	MinLength *int   `yaml:"minLength,omitempty" json:"minLength,omitempty"`
+	MaxLength *int   `yaml:"maxLength,omitempty" json:"maxLength,omitempty"`
+	Pattern   string `yaml:"pattern,omitempty" json:"pattern,omitempty"`
+
+	Minimum          *float64 `yaml:"minimum,omitempty" json:"minimum,omitempty"`
+	Maximum          *float64 `yaml:"maximum,omitempty" json:"maximum,omitempty"`
+	ExclusiveMinimum any      `yaml:"exclusiveMinimum,omitempty" json:"exclusiveMinimum,omitempty"` // a bool in OpenAPI 3.0, a number in 3.1
+	ExclusiveMaximum any      `yaml:"exclusiveMaximum,omitempty" json:"exclusiveMaximum,omitempty"` // a bool in OpenAPI 3.0, a number in 3.1
+
+	MinItems *int `yaml:"minItems,omitempty" json:"minItems,omitempty"`
+	MaxItems *int `yaml:"maxItems,omitempty" json:"maxItems,omitempty"`
+}
+
+// AdditionalProperties is either a boolean allowing or forbidding properties
+// not listed in the schema, or the schema they must satisfy
+type AdditionalProperties struct {
+	Allowed bool
+	Schema  *Schema
+}
+
+// UnmarshalYAML parses a boolean or a schema.
+func (a *AdditionalProperties) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return node.Decode(&a.Allowed)
+	}
+	a.Allowed = true
+	return node.Decode(&a.Schema)
+}
+
+// MarshalYAML returns the schema, or the boolean if there is none.
+func (a AdditionalProperties) MarshalYAML() (any, error) {
+	if a.Schema != nil {
+		return a.Schema, nil
+	}
+	return a.Allowed, nil
+}
+
+// MarshalJSON returns the schema, or the boolean if there is none.
+func (a AdditionalProperties) MarshalJSON() ([]byte, error) {
+	if a.Schema != nil {
+		return json.Marshal(a.Schema)
+	}
+	return json.Marshal(a.Allowed)
+}
+
+// Parse parses an OpenAPI 3 document in YAML or JSON.
+func Parse(data []byte) (*Document, error) {
+	var doc Document
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAPI document: %w", err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		return nil, fmt.Errorf("unsupported OpenAPI version %q, must be 3.x", doc.OpenAPI)
+	}
+
+	doc.templates = make(map[string]string, len(doc.Paths))
+	for path := range doc.Paths {
+		doc.templates[template(path)] = path
+	}
+	return &doc, nil
+}
+
+// IsJSON reports whether contentType is JSON, e.g. application/json or application/problem+json.
+func IsJSON(contentType string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.TrimSpace(mediaType)
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// sortedKeys returns the keys of m in order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
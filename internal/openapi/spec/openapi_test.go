@@ -0,0 +1,28 @@
+package spec
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{name: "3.0", data: "openapi: 3.0.3\npaths: {}\n"},
+		{name: "3.1 JSON", data: `{"openapi": "3.1.0", "paths": {}}`},
+		{name: "swagger", data: "swagger: '2.0'\n", wantErr: `unsupported OpenAPI version ""`},
+		{name: "invalid", data: "openapi: [", wantErr: "failed to parse OpenAPI document"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.data))
+			if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
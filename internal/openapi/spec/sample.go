@@ -0,0 +1,204 @@
+package spec
+
+import (
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+// maxRefs limits how many references are followed from one reference to the next
+const maxRefs = 8
+
+// Sample generates a value for schema, preferring its example, default or first enum value.
+func (doc *Document) Sample(schema *Schema) any {
+	return doc.sample(schema, nil)
+}
+
+// sample generates a value for schema, preferring its example, default or first enum value.
+// refs holds the schema references being expanded, so recursive schemas end instead of repeating.
+func (doc *Document) sample(schema *Schema, refs []string) any {
+	if schema != nil && schema.Ref != "" {
+		if slices.Contains(refs, schema.Ref) {
+			return nil
+		}
+		refs = append(slices.Clip(refs), schema.Ref)
+	}
+
+	schema = doc.ResolveSchema(schema)
+	if schema == nil {
+		return nil
+	}
+
+	switch {
+	case schema.Example != nil:
+		return schema.Example
+	case len(schema.Examples) > 0:
+		return schema.Examples[0]
+	case schema.Default != nil:
+		return schema.Default
+	case len(schema.Enum) > 0:
+		return schema.Enum[0]
+	case len(schema.AllOf) > 0:
+		// merge the properties of all schemas
+		merged := map[string]any{}
+		for _, sub := range schema.AllOf {
+			if object, ok := doc.sample(sub, refs).(map[string]any); ok {
+				for name, value := range object {
+					merged[name] = value
+				}
+			}
+		}
+		return merged
+	case len(schema.OneOf) > 0:
+		return doc.sample(schema.OneOf[0], refs)
+	case len(schema.AnyOf) > 0:
+		return doc.sample(schema.AnyOf[0], refs)
+	}
+
+	switch schema.typeName() {
+	case "object", "":
+		if schema.Properties == nil && schema.typeName() == "" {
+			return nil
+		}
+		object := map[string]any{}
+		for name, property := range schema.Properties {
+			if value := doc.sample(property, refs); value != nil {
+				object[name] = value
+			}
+		}
+		return object
+	case "array":
+		if item := doc.sample(schema.Items, refs); item != nil {
+			return []any{item}
+		}
+		return []any{}
+	case "string":
+		return sampleString(schema.Format)
+	case "integer":
+		return 0
+	case "number":
+		return 0.0
+	case "boolean":
+		return true
+	}
+	return nil
+}
+
+// typeName returns the type of the schema, ignoring "null" in OpenAPI 3.1 type lists.
+func (s *Schema) typeName() string {
+	switch t := s.Type.(type) {
+	case string:
+		return t
+	case []any:
+		for _, name := range t {
+			if name, ok := name.(string); ok && name != "null" {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+// sampleString returns a sample string for a string format.
+func sampleString(format string) string {
+	switch format {
+	case "date-time":
+		return "2024-01-01T00:00:00Z"
+	case "date":
+		return "2024-01-01"
+	case "time":
+		return "00:00:00"
+	case "uuid":
+		return "3fa85f64-5717-4562-b3fc-2c963f66afa6"
+	case "email":
+		return "user@example.com"
+	case "uri", "url":
+		return "https://example.com"
+	case "hostname":
+		return "example.com"
+	case "ipv4":
+		return "192.0.2.1"
+	case "ipv6":
+		return "2001:db8::1"
+	case "byte":
+		return "c3RyaW5n"
+	}
+	return "string"
+}
+
+// ResolveSchema follows $ref to a schema in the components of the document.
+func (doc *Document) ResolveSchema(schema *Schema) *Schema {
+	for seen := 0; schema != nil && schema.Ref != "" && seen <= maxRefs; seen++ {
+		schema = doc.Components.Schemas[refName(schema.Ref, "schemas")]
+	}
+	if schema != nil && schema.Ref != "" {
+		return nil
+	}
+	return schema
+}
+
+// ResolveResponse follows $ref to a response in the components of the document.
+func (doc *Document) ResolveResponse(response *Response) *Response {
+	for seen := 0; response != nil && response.Ref != "" && seen <= maxRefs; seen++ {
+		response = doc.Components.Responses[refName(response.Ref, "responses")]
+	}
+	if response != nil && response.Ref != "" {
+		return nil
+	}
+	return response
+}
+
+// ResolveExample follows $ref to an example in the components of the document.
+func (doc *Document) ResolveExample(example *Example) *Example {
+	for seen := 0; example != nil && example.Ref != "" && seen <= maxRefs; seen++ {
+		example = doc.Components.Examples[refName(example.Ref, "examples")]
+	}
+	if example != nil && example.Ref != "" {
+		return nil
+	}
+	return example
+}
+
+// ResolveHeader follows $ref to a header in the components of the document.
+func (doc *Document) ResolveHeader(header *Header) *Header {
+	for seen := 0; header != nil && header.Ref != "" && seen <= maxRefs; seen++ {
+		header = doc.Components.Headers[refName(header.Ref, "headers")]
+	}
+	if header != nil && header.Ref != "" {
+		return nil
+	}
+	return header
+}
+
+// ResolveParameter follows $ref to a parameter in the components of the document.
+func (doc *Document) ResolveParameter(parameter *Parameter) *Parameter {
+	for seen := 0; parameter != nil && parameter.Ref != "" && seen <= maxRefs; seen++ {
+		parameter = doc.Components.Parameters[refName(parameter.Ref, "parameters")]
+	}
+	if parameter != nil && parameter.Ref != "" {
+		return nil
+	}
+	return parameter
+}
+
+// ResolveRequestBody follows $ref to a request body in the components of the document.
+func (doc *Document) ResolveRequestBody(requestBody *RequestBody) *RequestBody {
+	for seen := 0; requestBody != nil && requestBody.Ref != "" && seen <= maxRefs; seen++ {
+		requestBody = doc.Components.RequestBodies[refName(requestBody.Ref, "requestBodies")]
+	}
+	if requestBody != nil && requestBody.Ref != "" {
+		return nil
+	}
+	return requestBody
+}
+
+// refName returns the name of a local reference to a component of the given kind,
+// e.g. "User" for "#/components/schemas/User". Other references yield an empty name.
+func refName(ref, kind string) string {
+	name, ok := strings.CutPrefix(ref, "#/components/"+kind+"/")
+	if !ok {
+		return ""
+	}
+	// unescape JSON pointer tokens
+	return strings.NewReplacer("~1", "/", "~0", "~").Replace(name)
+}
@@ -0,0 +1,582 @@
+package spec
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"mime"
+	"net/http"
+	"net/mail"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Locations of request values
+const (
+	InPath   = "path"
+	InQuery  = "query"
+	InHeader = "header"
+	InCookie = "cookie"
+	InBody   = "body"
+)
+
+// Violation is a part of a request that does not satisfy its schema
+type Violation struct {
+	In      string `json:"in"`
+	Name    string `json:"name,omitempty"` // parameter name, or JSON path in the body
+	Message string `json:"message"`
+}
+
+// template returns a path template without parameter names, e.g. "/users/{}" for
+// "/users/{id}", so paths can be compared regardless of how parameters are named.
+func template(path string) string {
+	path = strings.TrimSuffix(path, "{$}")
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			segments[i] = "{}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// parameterNames returns the names of the parameters of a path in order.
+func parameterNames(path string) []string {
+	var names []string
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") && segment != "{$}" {
+			names = append(names, strings.TrimSuffix(segment[1:len(segment)-1], "..."))
+		}
+	}
+	return names
+}
+
+// ValidateRequest validates req against the operation of the document for its method
+// on the path matching the path template, e.g. "/users/{userId}" for "/users/{id}".
+// pathValues are the values of the path parameters in order. Requests without a
+// matching operation are not validated.
+func (doc *Document) ValidateRequest(req *http.Request, path string, pathValues []string, body []byte) []Violation {
+	docPath, ok := doc.templates[template(path)]
+	if !ok {
+		return nil
+	}
+	pathItem := doc.Paths[docPath]
+	operation, ok := pathItem.Operations()[strings.ToUpper(req.Method)]
+	if !ok {
+		return nil
+	}
+
+	// path values by the parameter names of the document
+	values := map[string]string{}
+	for i, name := range parameterNames(docPath) {
+		if i < len(pathValues) {
+			values[name] = pathValues[i]
+		}
+	}
+
+	// operation parameters override path item parameters with the same name and location
+	parameters := map[string]*Parameter{}
+	var order []string
+	for _, parameter := range append(append([]*Parameter{}, pathItem.Parameters...), operation.Parameters...) {
+		parameter = doc.ResolveParameter(parameter)
+		if parameter == nil {
+			continue
+		}
+		key := parameter.In + " " + parameter.Name
+		if _, ok := parameters[key]; !ok {
+			order = append(order, key)
+		}
+		parameters[key] = parameter
+	}
+
+	var violations []Violation
+	for _, key := range order {
+		parameter := parameters[key]
+
+		var found []string
+		switch parameter.In {
+		case InPath:
+			if value, ok := values[parameter.Name]; ok {
+				found = []string{value}
+			}
+		case InQuery:
+			found = req.URL.Query()[parameter.Name]
+		case InHeader:
+			found = req.Header.Values(parameter.Name)
+		case InCookie:
+			if cookie, err := req.Cookie(parameter.Name); err == nil {
+				found = []string{cookie.Value}
+			}
+		default:
+			continue
+		}
+
+		// path parameters are always required
+		required := parameter.Required || parameter.In == InPath
+		violations = append(violations, doc.ValidateParameter(parameter.In, parameter.Name, found, required, parameter.Schema)...)
+	}
+
+	if requestBody := doc.ResolveRequestBody(operation.RequestBody); requestBody != nil {
+		violations = append(violations, doc.validateRequestBody(req, requestBody, body)...)
+	}
+	return violations
+}
+
+// validateRequestBody validates body against the media type of the request body matching its content type.
+func (doc *Document) validateRequestBody(req *http.Request, requestBody *RequestBody, body []byte) []Violation {
+	if len(body) == 0 {
+		if requestBody.Required {
+			return []Violation{{In: InBody, Message: "is required"}}
+		}
+		return nil
+	}
+
+	contentType := req.Header.Get("Content-Type")
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	content, ok := requestBody.Content[mediaType]
+	if !ok {
+		// fall back to wildcard media types such as application/* or */*
+		major, _, _ := strings.Cut(mediaType, "/")
+		if content, ok = requestBody.Content[major+"/*"]; !ok {
+			content, ok = requestBody.Content["*/*"]
+		}
+	}
+	if !ok {
+		if len(requestBody.Content) == 0 {
+			return nil
+		}
+		return []Violation{{In: InBody, Message: fmt.Sprintf("unsupported content type %q", contentType)}}
+	}
+
+	if !IsJSON(mediaType) {
+		return nil
+	}
+	return doc.ValidateBody(body, content.Schema)
+}
+
+// ValidateBody validates a JSON body against schema.
+func (doc *Document) ValidateBody(body []byte, schema *Schema) []Violation {
+	var value any
+	if err := json.Unmarshal(body, &value); err != nil {
+		return []Violation{{In: InBody, Message: fmt.Sprintf("invalid JSON: %v", err)}}
+	}
+
+	var violations []Violation
+	doc.validate(schema, value, "$", func(name, message string) {
+		violations = append(violations, Violation{In: InBody, Name: name, Message: message})
+	})
+	return violations
+}
+
+// ValidateParameters validates the parameters found in one location against an object
+// schema listing them as its properties, e.g. the query parameters of a request.
+func (doc *Document) ValidateParameters(in string, schema *Schema, lookup func(name string) []string) []Violation {
+	schema = doc.ResolveSchema(schema)
+	if schema == nil {
+		return nil
+	}
+
+	// required parameters need not be listed as properties
+	required := map[string]bool{}
+	for _, name := range schema.Required {
+		required[name] = true
+	}
+	names := map[string]bool{}
+	for name := range schema.Properties {
+		names[name] = true
+	}
+	for name := range required {
+		names[name] = true
+	}
+
+	var violations []Violation
+	for _, name := range sortedKeys(names) {
+		violations = append(violations, doc.ValidateParameter(in, name, lookup(name), required[name], schema.Properties[name])...)
+	}
+	return violations
+}
+
+// ValidateParameter validates the values of a parameter against schema, after converting
+// them to the type of the schema. Array parameters may be repeated or comma separated.
+func (doc *Document) ValidateParameter(in, name string, values []string, required bool, schema *Schema) []Violation {
+	if len(values) == 0 {
+		if required {
+			return []Violation{{In: in, Name: name, Message: "is required"}}
+		}
+		return nil
+	}
+
+	schema = doc.ResolveSchema(schema)
+	if schema == nil {
+		return nil
+	}
+
+	var value any
+	var err error
+	if schema.typeName() == "array" {
+		if len(values) == 1 {
+			values = strings.Split(values[0], ",")
+		}
+		items := make([]any, len(values))
+		for i, v := range values {
+			if items[i], err = convert(v, doc.ResolveSchema(schema.Items)); err != nil {
+				break
+			}
+		}
+		value = items
+	} else {
+		value, err = convert(values[0], schema)
+	}
+	if err != nil {
+		return []Violation{{In: in, Name: name, Message: err.Error()}}
+	}
+
+	var violations []Violation
+	doc.validate(schema, value, name, func(path, message string) {
+		violations = append(violations, Violation{In: in, Name: path, Message: message})
+	})
+	return violations
+}
+
+// convert converts a parameter value to the type of schema.
+func convert(value string, schema *Schema) (any, error) {
+	if schema == nil {
+		return value, nil
+	}
+
+	switch schema.typeName() {
+	case "integer":
+		i, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("must be an integer")
+		}
+		return float64(i), nil
+	case "number":
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("must be a number")
+		}
+		return f, nil
+	case "boolean":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("must be a boolean")
+		}
+		return b, nil
+	}
+	return value, nil
+}
+
+// CheckSchema reports the first problem keeping schema from validating values: a
+// pattern that does not compile or a $ref that cannot be resolved in the document.
+func (doc *Document) CheckSchema(schema *Schema) error {
+	return doc.checkSchema(schema, "$", nil)
+}
+
+// checkSchema checks schema and its subschemas, following each $ref once so recursive schemas terminate.
+func (doc *Document) checkSchema(schema *Schema, path string, refs []string) error {
+	if schema == nil {
+		return nil
+	}
+	if schema.Ref != "" {
+		for _, ref := range refs {
+			if ref == schema.Ref {
+				return nil
+			}
+		}
+		resolved := doc.ResolveSchema(schema)
+		if resolved == nil {
+			return fmt.Errorf("%s: $ref %q cannot be resolved", path, schema.Ref)
+		}
+		refs = append(refs[:len(refs):len(refs)], schema.Ref)
+		schema = resolved
+	}
+
+	if schema.Pattern != "" {
+		if _, err := compilePattern(schema.Pattern); err != nil {
+			return fmt.Errorf("%s: invalid pattern %q: %w", path, schema.Pattern, err)
+		}
+	}
+
+	for _, name := range sortedKeys(schema.Properties) {
+		if err := doc.checkSchema(schema.Properties[name], path+"."+name, refs); err != nil {
+			return err
+		}
+	}
+	if err := doc.checkSchema(schema.Items, path+"[]", refs); err != nil {
+		return err
+	}
+	if schema.AdditionalProperties != nil {
+		if err := doc.checkSchema(schema.AdditionalProperties.Schema, path+".*", refs); err != nil {
+			return err
+		}
+	}
+	for _, composition := range []struct {
+		keyword string
+		schemas []*Schema
+	}{{"allOf", schema.AllOf}, {"anyOf", schema.AnyOf}, {"oneOf", schema.OneOf}} {
+		for i, sub := range composition.schemas {
+			if err := doc.checkSchema(sub, fmt.Sprintf("%s.%s[%d]", path, composition.keyword, i), refs); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validate checks value against schema, reporting each violation with the JSON path of the offending value.
+func (doc *Document) validate(schema *Schema, value any, path string, report func(path, message string)) {
+	schema = doc.ResolveSchema(schema)
+	if schema == nil {
+		return
+	}
+
+	if value == nil {
+		if schema.Type != nil && !schema.Nullable && !schema.allowsNull() {
+			report(path, "must not be null")
+		}
+		return
+	}
+
+	for _, sub := range schema.AllOf {
+		doc.validate(sub, value, path, report)
+	}
+	if len(schema.AnyOf) > 0 && doc.matching(schema.AnyOf, value) == 0 {
+		report(path, "must match at least one of the anyOf schemas")
+	}
+	if len(schema.OneOf) > 0 && doc.matching(schema.OneOf, value) != 1 {
+		report(path, "must match exactly one of the oneOf schemas")
+	}
+
+	if len(schema.Enum) > 0 && !containsValue(schema.Enum, value) {
+		report(path, fmt.Sprintf("must be one of %v", schema.Enum))
+		return
+	}
+
+	if typeName := schema.typeName(); typeName != "" && !hasType(value, typeName) {
+		report(path, "must be "+article(typeName)+" "+typeName)
+		return
+	}
+
+	switch v := value.(type) {
+	case string:
+		validateString(schema, v, path, report)
+	case float64:
+		validateNumber(schema, v, path, report)
+	case []any:
+		if schema.MinItems != nil && len(v) < *schema.MinItems {
+			report(path, fmt.Sprintf("must have at least %d items", *schema.MinItems))
+		}
+		if schema.MaxItems != nil && len(v) > *schema.MaxItems {
+			report(path, fmt.Sprintf("must have at most %d items", *schema.MaxItems))
+		}
+		for i, item := range v {
+			doc.validate(schema.Items, item, fmt.Sprintf("%s[%d]", path, i), report)
+		}
+	case map[string]any:
+		for _, name := range schema.Required {
+			if _, ok := v[name]; !ok {
+				report(path+"."+name, "is required")
+			}
+		}
+		for _, name := range sortedKeys(v) {
+			if property, ok := schema.Properties[name]; ok {
+				doc.validate(property, v[name], path+"."+name, report)
+				continue
+			}
+			if additional := schema.AdditionalProperties; additional != nil {
+				if !additional.Allowed {
+					report(path+"."+name, "is not allowed")
+				} else {
+					doc.validate(additional.Schema, v[name], path+"."+name, report)
+				}
+			}
+		}
+	}
+}
+
+// matching returns how many of schemas value satisfies.
+func (doc *Document) matching(schemas []*Schema, value any) int {
+	matching := 0
+	for _, schema := range schemas {
+		valid := true
+		doc.validate(schema, value, "", func(string, string) { valid = false })
+		if valid {
+			matching++
+		}
+	}
+	return matching
+}
+
+// allowsNull reports whether an OpenAPI 3.1 type list includes "null".
+func (s *Schema) allowsNull() bool {
+	types, ok := s.Type.([]any)
+	if !ok {
+		return false
+	}
+	for _, t := range types {
+		if t == "null" {
+			return true
+		}
+	}
+	return false
+}
+
+// hasType reports whether a decoded JSON value is of the JSON schema type.
+func hasType(value any, typeName string) bool {
+	switch typeName {
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "integer":
+		f, ok := value.(float64)
+		return ok && f == math.Trunc(f)
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	}
+	return true
+}
+
+// article returns the indefinite article for a type name.
+func article(typeName string) string {
+	if strings.ContainsRune("aeiou", rune(typeName[0])) {
+		return "an"
+	}
+	return "a"
+}
+
+// containsValue reports whether values contains value, comparing numbers by value.
+func containsValue(values []any, value any) bool {
+	for _, v := range values {
+		if reflect.DeepEqual(normalize(v), normalize(value)) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalize converts the integers decoded from YAML to float64, as decoded from JSON.
+func normalize(value any) any {
+	switch v := value.(type) {
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	case []any:
+		normalized := make([]any, len(v))
+		for i, item := range v {
+			normalized[i] = normalize(item)
+		}
+		return normalized
+	case map[string]any:
+		normalized := make(map[string]any, len(v))
+		for key, item := range v {
+			normalized[key] = normalize(item)
+		}
+		return normalized
+	}
+	return value
+}
+
+// uuidPattern matches a UUID in its canonical form
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// validateString checks the length, pattern and format of a string.
+func validateString(schema *Schema, value, path string, report func(path, message string)) {
+	length := utf8.RuneCountInString(value)
+	if schema.MinLength != nil && length < *schema.MinLength {
+		report(path, fmt.Sprintf("must be at least %d characters long", *schema.MinLength))
+	}
+	if schema.MaxLength != nil && length > *schema.MaxLength {
+		report(path, fmt.Sprintf("must be at most %d characters long", *schema.MaxLength))
+	}
+
+	if schema.Pattern != "" {
+		re, err := compilePattern(schema.Pattern)
+		if err != nil {
+			report(path, fmt.Sprintf("has an invalid pattern %q in its schema", schema.Pattern))
+		} else if !re.MatchString(value) {
+			report(path, fmt.Sprintf("must match pattern %q", schema.Pattern))
+		}
+	}
+
+	var err error
+	switch schema.Format {
+	case "date-time":
+		_, err = time.Parse(time.RFC3339, value)
+	case "date":
+		_, err = time.Parse(time.DateOnly, value)
+	case "uuid":
+		if !uuidPattern.MatchString(value) {
+			err = fmt.Errorf("invalid uuid")
+		}
+	case "email":
+		_, err = mail.ParseAddress(value)
+	}
+	if err != nil {
+		report(path, fmt.Sprintf("must be a valid %s", schema.Format))
+	}
+}
+
+// validateNumber checks the bounds of a number.
+func validateNumber(schema *Schema, value float64, path string, report func(path, message string)) {
+	// OpenAPI 3.0 makes minimum and maximum exclusive with a boolean,
+	// OpenAPI 3.1 sets the exclusive bounds as numbers
+	exclusiveMin, exclusiveMax := schema.ExclusiveMinimum == true, schema.ExclusiveMaximum == true
+
+	if schema.Minimum != nil {
+		if exclusiveMin && value <= *schema.Minimum {
+			report(path, fmt.Sprintf("must be greater than %v", *schema.Minimum))
+		} else if value < *schema.Minimum {
+			report(path, fmt.Sprintf("must be at least %v", *schema.Minimum))
+		}
+	}
+	if bound, ok := normalize(schema.ExclusiveMinimum).(float64); ok && value <= bound {
+		report(path, fmt.Sprintf("must be greater than %v", bound))
+	}
+
+	if schema.Maximum != nil {
+		if exclusiveMax && value >= *schema.Maximum {
+			report(path, fmt.Sprintf("must be less than %v", *schema.Maximum))
+		} else if value > *schema.Maximum {
+			report(path, fmt.Sprintf("must be at most %v", *schema.Maximum))
+		}
+	}
+	if bound, ok := normalize(schema.ExclusiveMaximum).(float64); ok && value >= bound {
+		report(path, fmt.Sprintf("must be less than %v", bound))
+	}
+}
+
+// patternCache holds compiled schema patterns keyed by their source
+var patternCache sync.Map
+
+// compilePattern returns the compiled form of a schema pattern, compiling it only once.
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if re, ok := patternCache.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	patternCache.Store(pattern, re)
+	return re, nil
+}
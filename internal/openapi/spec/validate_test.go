@@ -0,0 +1,302 @@
+package spec
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// parseSchema decodes a YAML schema for a test.
+func parseSchema(t *testing.T, data string) *Schema {
+	t.Helper()
+	var schema Schema
+	if err := yaml.Unmarshal([]byte(data), &schema); err != nil {
+		t.Fatalf("parse schema: %v", err)
+	}
+	return &schema
+}
+
+// messages formats violations as "name: message" for comparison.
+func messages(violations []Violation) []string {
+	var formatted []string
+	for _, v := range violations {
+		formatted = append(formatted, v.Name+": "+v.Message)
+	}
+	return formatted
+}
+
+func TestValidateBody(t *testing.T) {
+	doc, err := Parse([]byte(`openapi: 3.0.3
+paths: {}
+components:
+  schemas:
+    Pet:
+      type: object
+      required: [name]
+      properties:
+        name: {type: string}
+`))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		schema string
+		body   string
+		want   []string
+	}{
+		{name: "valid", schema: "type: object", body: `{}`},
+		{name: "invalid JSON", schema: "type: object", body: `{`, want: []string{": invalid JSON: unexpected end of JSON input"}},
+		{name: "type", schema: "type: object", body: `[]`, want: []string{"$: must be an object"}},
+		{name: "integer", schema: "type: integer", body: `1.5`, want: []string{"$: must be an integer"}},
+		{name: "null", schema: "type: string", body: `null`, want: []string{"$: must not be null"}},
+		{name: "nullable", schema: "{type: string, nullable: true}", body: `null`},
+		{name: "3.1 null type", schema: "type: [string, 'null']", body: `null`},
+		{name: "enum", schema: "enum: [a, b]", body: `"c"`, want: []string{"$: must be one of [a b]"}},
+		{name: "integer enum", schema: "enum: [1, 2]", body: `2`},
+		{
+			name:   "required and properties",
+			schema: "{type: object, required: [name, age], properties: {name: {type: string}, age: {type: integer}}}",
+			body:   `{"name": 1}`,
+			want:   []string{"$.age: is required", "$.name: must be a string"},
+		},
+		{
+			name:   "additional properties not allowed",
+			schema: "{type: object, properties: {name: {type: string}}, additionalProperties: false}",
+			body:   `{"name": "a", "extra": 1}`,
+			want:   []string{"$.extra: is not allowed"},
+		},
+		{
+			name:   "additional properties schema",
+			schema: "{type: object, additionalProperties: {type: integer}}",
+			body:   `{"a": 1, "b": "2"}`,
+			want:   []string{"$.b: must be an integer"},
+		},
+		{name: "min length", schema: "{type: string, minLength: 2}", body: `"é"`, want: []string{"$: must be at least 2 characters long"}},
+		{name: "max length", schema: "{type: string, maxLength: 1}", body: `"ab"`, want: []string{"$: must be at most 1 characters long"}},
+		{name: "pattern", schema: "{type: string, pattern: '^[a-z]+$'}", body: `"A1"`, want: []string{`$: must match pattern "^[a-z]+$"`}},
+		{name: "invalid pattern", schema: "{type: string, pattern: '[a-z'}", body: `"a"`, want: []string{`$: has an invalid pattern "[a-z" in its schema`}},
+		{name: "uuid", schema: "{type: string, format: uuid}", body: `"123"`, want: []string{"$: must be a valid uuid"}},
+		{name: "valid uuid", schema: "{type: string, format: uuid}", body: `"0b5f8c1e-7a4e-4c1d-9d7b-3f1c2a6e8b90"`},
+		{name: "date-time", schema: "{type: string, format: date-time}", body: `"2024-01-01"`, want: []string{"$: must be a valid date-time"}},
+		{name: "date", schema: "{type: string, format: date}", body: `"2024-01-01"`},
+		{name: "email", schema: "{type: string, format: email}", body: `"nobody"`, want: []string{"$: must be a valid email"}},
+		{name: "minimum", schema: "{type: number, minimum: 1}", body: `0.5`, want: []string{"$: must be at least 1"}},
+		{name: "maximum", schema: "{type: number, maximum: 1}", body: `1`},
+		{name: "3.0 exclusive maximum", schema: "{type: number, maximum: 1, exclusiveMaximum: true}", body: `1`, want: []string{"$: must be less than 1"}},
+		{name: "3.1 exclusive minimum", schema: "{type: number, exclusiveMinimum: 1}", body: `1`, want: []string{"$: must be greater than 1"}},
+		{
+			name:   "items",
+			schema: "{type: array, minItems: 3, items: {type: integer}}",
+			body:   `[1, "2"]`,
+			want:   []string{"$: must have at least 3 items", "$[1]: must be an integer"},
+		},
+		{name: "max items", schema: "{type: array, maxItems: 1}", body: `[1, 2]`, want: []string{"$: must have at most 1 items"}},
+		{name: "allOf", schema: "allOf: [{type: object, required: [a]}, {type: object, required: [b]}]", body: `{"a": 1}`, want: []string{"$.b: is required"}},
+		{name: "anyOf", schema: "anyOf: [{type: string}, {type: integer}]", body: `true`, want: []string{"$: must match at least one of the anyOf schemas"}},
+		{name: "oneOf", schema: "oneOf: [{type: number}, {type: integer}]", body: `1`, want: []string{"$: must match exactly one of the oneOf schemas"}},
+		{name: "oneOf match", schema: "oneOf: [{type: string}, {type: integer}]", body: `1`},
+		{name: "ref", schema: "$ref: '#/components/schemas/Pet'", body: `{}`, want: []string{"$.name: is required"}},
+		{name: "unresolved ref", schema: "$ref: '#/components/schemas/Missing'", body: `1`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := messages(doc.ValidateBody([]byte(tt.body), parseSchema(t, tt.schema)))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ValidateBody() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateParameter(t *testing.T) {
+	tests := []struct {
+		name     string
+		schema   string
+		values   []string
+		required bool
+		want     []string
+	}{
+		{name: "missing", schema: "type: string"},
+		{name: "missing required", schema: "type: string", required: true, want: []string{"limit: is required"}},
+		{name: "integer", schema: "{type: integer, maximum: 100}", values: []string{"50"}},
+		{name: "not an integer", schema: "type: integer", values: []string{"1.5"}, want: []string{"limit: must be an integer"}},
+		{name: "integer bounds", schema: "{type: integer, maximum: 100}", values: []string{"101"}, want: []string{"limit: must be at most 100"}},
+		{name: "number", schema: "type: number", values: []string{"x"}, want: []string{"limit: must be a number"}},
+		{name: "boolean", schema: "type: boolean", values: []string{"yes"}, want: []string{"limit: must be a boolean"}},
+		{name: "comma separated array", schema: "{type: array, items: {type: integer}}", values: []string{"1,2,3"}},
+		{name: "repeated array", schema: "{type: array, maxItems: 1, items: {type: integer}}", values: []string{"1", "2"}, want: []string{"limit: must have at most 1 items"}},
+		{name: "array items", schema: "{type: array, items: {type: integer}}", values: []string{"1,a"}, want: []string{"limit: must be an integer"}},
+		{name: "string enum", schema: "{type: string, enum: [asc, desc]}", values: []string{"up"}, want: []string{"limit: must be one of [asc desc]"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := messages((&Document{}).ValidateParameter(InQuery, "limit", tt.values, tt.required, parseSchema(t, tt.schema)))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ValidateParameter() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateRequest(t *testing.T) {
+	doc, err := Parse([]byte(`openapi: 3.0.3
+paths:
+  /users/{userId}:
+    parameters:
+    - {name: userId, in: path, schema: {type: string}}
+    - {name: verbose, in: query, schema: {type: boolean}}
+    put:
+      parameters:
+      - {name: userId, in: path, schema: {type: integer}}
+      - {$ref: '#/components/parameters/Trace'}
+      - {name: session, in: cookie, required: true, schema: {type: string, minLength: 4}}
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema: {type: object, required: [name]}
+          text/plain: {}
+components:
+  parameters:
+    Trace: {name: X-Trace, in: header, schema: {type: string, format: uuid}}
+`))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		userID      string
+		query       string
+		contentType string
+		body        string
+		cookie      string
+		trace       string
+		want        []Violation
+	}{
+		{
+			name:        "valid",
+			method:      http.MethodPut,
+			path:        "/users/{id}",
+			userID:      "1",
+			contentType: "application/json; charset=utf-8",
+			body:        `{"name": "a"}`,
+			cookie:      "abcd",
+		},
+		{name: "undocumented path", method: http.MethodPut, path: "/groups/{id}"},
+		{name: "undocumented method", method: http.MethodGet, path: "/users/{id}"},
+		{
+			name:   "parameters",
+			method: http.MethodPut,
+			path:   "/users/{id}",
+			userID: "me",
+			query:  "verbose=maybe",
+			body:   `plain`,
+			cookie: "abc",
+			trace:  "123",
+			want: []Violation{
+				{In: InPath, Name: "userId", Message: "must be an integer"},
+				{In: InQuery, Name: "verbose", Message: "must be a boolean"},
+				{In: InHeader, Name: "X-Trace", Message: "must be a valid uuid"},
+				{In: InCookie, Name: "session", Message: "must be at least 4 characters long"},
+				{In: InBody, Message: `unsupported content type ""`},
+			},
+		},
+		{
+			name:   "missing required",
+			method: http.MethodPut,
+			path:   "/users/{id}",
+			userID: "1",
+			want: []Violation{
+				{In: InCookie, Name: "session", Message: "is required"},
+				{In: InBody, Message: "is required"},
+			},
+		},
+		{
+			name:        "body schema",
+			method:      http.MethodPut,
+			path:        "/users/{id}",
+			userID:      "1",
+			contentType: "application/json",
+			body:        `{}`,
+			cookie:      "abcd",
+			want:        []Violation{{In: InBody, Name: "$.name", Message: "is required"}},
+		},
+		{
+			name:        "non JSON body",
+			method:      http.MethodPut,
+			path:        "/users/{id}",
+			userID:      "1",
+			contentType: "text/plain",
+			body:        `{`,
+			cookie:      "abcd",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/users/1?"+tt.query, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: "session", Value: tt.cookie})
+			}
+			if tt.trace != "" {
+				req.Header.Set("X-Trace", tt.trace)
+			}
+
+			got := doc.ValidateRequest(req, tt.path, []string{tt.userID}, []byte(tt.body))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ValidateRequest() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckSchema(t *testing.T) {
+	doc, err := Parse([]byte(`openapi: 3.0.3
+paths: {}
+components:
+  schemas:
+    Node:
+      type: object
+      properties:
+        children: {type: array, items: {$ref: '#/components/schemas/Node'}}
+`))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		schema  string
+		wantErr string
+	}{
+		{name: "valid", schema: "{type: string, pattern: '^[a-z]+$'}"},
+		{name: "recursive ref", schema: "$ref: '#/components/schemas/Node'"},
+		{name: "invalid pattern", schema: "{properties: {name: {pattern: '[a-z'}}}", wantErr: `$.name: invalid pattern "[a-z"`},
+		{name: "unresolved ref", schema: "{items: {$ref: '#/components/schemas/Missing'}}", wantErr: `$[]: $ref "#/components/schemas/Missing" cannot be resolved`},
+		{name: "additional properties", schema: "{additionalProperties: {pattern: '('}}", wantErr: `$.*: invalid pattern "("`},
+		{name: "oneOf", schema: "{oneOf: [{type: string}, {pattern: '('}]}", wantErr: `$.oneOf[1]: invalid pattern "("`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := doc.CheckSchema(parseSchema(t, tt.schema))
+			if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
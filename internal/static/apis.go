@@ -0,0 +1,202 @@
+package static
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+// errConflictingPath is returned by putRuntimeAPI when a StaticAPI cannot be registered next to the others.
+var errConflictingPath = errors.New("conflicting path")
+
+// handleAPIs lists the runtime StaticAPIs on GET, creates one on POST and deletes all of them on DELETE.
+func (s *Server) handleAPIs(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+		writeStaticAPIs(w, http.StatusOK, StaticAPIs{StaticAPIs: s.runtimeAPIs})
+
+	case http.MethodPost:
+		staticAPI, err := decodeStaticAPI(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if staticAPI.Name == "" {
+			staticAPI.Name = newUUID()
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if s.runtimeAPIIndex(staticAPI.Name) >= 0 {
+			http.Error(w, fmt.Sprintf("StaticAPI %s already exists", staticAPI.Name), http.StatusConflict)
+			return
+		}
+		if err := s.putRuntimeAPI(staticAPI); err != nil {
+			http.Error(w, err.Error(), putStatus(err))
+			return
+		}
+		writeStaticAPIs(w, http.StatusCreated, staticAPI)
+
+	case http.MethodDelete:
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		s.runtimeAPIs = nil
+		s.rebuild()
+		zap.L().Info("runtime StaticAPIs deleted")
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// handleAPI returns the named runtime StaticAPI on GET, creates or replaces it on PUT and deletes it on DELETE.
+func (s *Server) handleAPI(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+
+	switch r.Method {
+	case http.MethodGet:
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+
+		i := s.runtimeAPIIndex(name)
+		if i < 0 {
+			http.NotFound(w, r)
+			return
+		}
+		writeStaticAPIs(w, http.StatusOK, s.runtimeAPIs[i])
+
+	case http.MethodPut:
+		staticAPI, err := decodeStaticAPI(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		staticAPI.Name = name
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		status := http.StatusOK
+		if s.runtimeAPIIndex(name) < 0 {
+			status = http.StatusCreated
+		}
+		if err := s.putRuntimeAPI(staticAPI); err != nil {
+			http.Error(w, err.Error(), putStatus(err))
+			return
+		}
+		writeStaticAPIs(w, status, staticAPI)
+
+	case http.MethodDelete:
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		i := s.runtimeAPIIndex(name)
+		if i < 0 {
+			http.NotFound(w, r)
+			return
+		}
+		s.runtimeAPIs = append(s.runtimeAPIs[:i:i], s.runtimeAPIs[i+1:]...)
+		s.rebuild()
+		zap.L().Info("runtime StaticAPI deleted", zap.String("name", name))
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// decodeStaticAPI decodes and validates a StaticAPI from a YAML or JSON request body.
+func decodeStaticAPI(r *http.Request) (StaticAPI, error) {
+	var staticAPI StaticAPI
+	if err := yaml.NewDecoder(r.Body).Decode(&staticAPI); err != nil {
+		return StaticAPI{}, fmt.Errorf("invalid StaticAPI: %w", err)
+	}
+	if err := staticAPI.Validate(); err != nil {
+		return StaticAPI{}, fmt.Errorf("invalid StaticAPI: %w", err)
+	}
+	staticAPI.source = sourceRuntime
+	return staticAPI, nil
+}
+
+// runtimeAPIIndex returns the index of the named runtime StaticAPI, or -1. s.mu must be held.
+func (s *Server) runtimeAPIIndex(name string) int {
+	for i, staticAPI := range s.runtimeAPIs {
+		if staticAPI.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// putRuntimeAPI adds or replaces a runtime StaticAPI and rebuilds the mux, unless rebuild
+// would skip it. rebuild registers the runtime StaticAPIs before the source StaticAPIs,
+// which give way on conflicts, so its path only has to be accepted next to the admin
+// endpoints and the other runtime StaticAPIs. s.mu must be held.
+func (s *Server) putRuntimeAPI(staticAPI StaticAPI) error {
+	others := []StaticAPI{}
+	for _, runtimeAPI := range s.runtimeAPIs {
+		if runtimeAPI.Name != staticAPI.Name {
+			others = append(others, runtimeAPI)
+		}
+	}
+	if err := staticAPI.conflict(others); err != nil {
+		return fmt.Errorf("%w: %w", errConflictingPath, err)
+	}
+
+	mux := http.NewServeMux()
+	s.registerAdmin(mux)
+	if handle(mux, staticAPI.Pattern(), http.NotFoundHandler()) != nil {
+		return fmt.Errorf("%w: %s conflicts with the /_static/ admin endpoints", errConflictingPath, staticAPI.describe())
+	}
+
+	candidate := staticAPI
+	candidate.bodyDir = s.cfg.StaticAPIsPath
+	if err := candidate.loadBodyFiles(); err != nil {
+		return fmt.Errorf("invalid StaticAPI: %w", err)
+	}
+
+	if i := s.runtimeAPIIndex(staticAPI.Name); i >= 0 {
+		s.runtimeAPIs[i] = staticAPI
+	} else {
+		s.runtimeAPIs = append(s.runtimeAPIs, staticAPI)
+	}
+	s.rebuild()
+
+	zap.L().Info("runtime StaticAPI stored", zap.String("name", staticAPI.Name), zap.String("path", staticAPI.Path))
+	return nil
+}
+
+// putStatus returns the status code for an error of putRuntimeAPI.
+func putStatus(err error) int {
+	if errors.Is(err, errConflictingPath) {
+		return http.StatusConflict
+	}
+	return http.StatusBadRequest
+}
+
+// writeStaticAPIs writes v as JSON, using the same field names as the YAML file format.
+func writeStaticAPIs(w http.ResponseWriter, status int, v any) {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		zap.L().Error("failed to encode StaticAPIs", zap.Error(err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	var document any
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		zap.L().Error("failed to encode StaticAPIs", zap.Error(err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, status, document)
+}
@@ -0,0 +1,150 @@
+package static
+
+import (
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/antonjah/static/internal/config"
+)
+
+const apisTestFile = `staticapis:
+- name: users
+  path: /users/{id}
+  methods:
+  - method: GET
+    status-code: 200
+    body: file
+- name: orders
+  path: /orders
+  methods:
+  - method: GET
+    status-code: 200
+    body: orders
+`
+
+// runtimeAPI returns the YAML of a runtime StaticAPI serving body on GET requests to path.
+func runtimeAPI(path, body string) string {
+	return "path: " + path + "\nmethods:\n- method: GET\n  status-code: 200\n  body: " + body + "\n"
+}
+
+// serve returns the status code and body of a request to s.
+func serve(s *Server, method, target, body string) (int, string) {
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(method, target, strings.NewReader(body)))
+	return w.Code, w.Body.String()
+}
+
+func TestHandleAPIs(t *testing.T) {
+	s := newTestServer(t, config.Config{}, apisTestFile)
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "list empty", method: "GET", target: "/_static/apis", wantStatus: 200, wantBody: `{"staticapis":[]}`},
+		{name: "get missing", method: "GET", target: "/_static/apis/ids", wantStatus: 404},
+		{name: "create", method: "PUT", target: "/_static/apis/ids", body: runtimeAPI("/ids/{id}", "one"), wantStatus: 201, wantBody: `"name":"ids"`},
+		{name: "serve created", method: "GET", target: "/ids/1", wantStatus: 200, wantBody: "one"},
+		{name: "replace", method: "PUT", target: "/_static/apis/ids", body: runtimeAPI("/ids/{id}", "two"), wantStatus: 200},
+		{name: "serve replaced", method: "GET", target: "/ids/1", wantStatus: 200, wantBody: "two"},
+		{name: "get", method: "GET", target: "/_static/apis/ids", wantStatus: 200, wantBody: `"path":"/ids/{id}"`},
+		{name: "create with a generated name", method: "POST", target: "/_static/apis", body: runtimeAPI("/generated", "generated"), wantStatus: 201, wantBody: `"name":"`},
+		{name: "create existing", method: "POST", target: "/_static/apis", body: "name: ids\n" + runtimeAPI("/other", "other"), wantStatus: 409, wantBody: "StaticAPI ids already exists"},
+		{
+			name:       "conflict with a runtime StaticAPI",
+			method:     "PUT",
+			target:     "/_static/apis/names",
+			body:       runtimeAPI("/ids/{name}", "names"),
+			wantStatus: 409,
+			wantBody:   `conflicting path: StaticAPI "names" (/ids/{name}) conflicts with StaticAPI "ids" (/ids/{id})`,
+		},
+		{
+			name:       "conflict with the admin endpoints",
+			method:     "PUT",
+			target:     "/_static/apis/admin",
+			body:       runtimeAPI("/_static/info", "info"),
+			wantStatus: 409,
+			wantBody:   `StaticAPI "admin" (/_static/info) conflicts with the /_static/ admin endpoints`,
+		},
+		{name: "invalid", method: "PUT", target: "/_static/apis/invalid", body: "path: /invalid\nmethods:\n- method: GET\n  status-code: 600\n", wantStatus: 400, wantBody: "invalid status-code"},
+		{name: "undecodable", method: "POST", target: "/_static/apis", body: "path: [", wantStatus: 400, wantBody: "invalid StaticAPI"},
+		{name: "list", method: "GET", target: "/_static/apis", wantStatus: 200, wantBody: `"path":"/generated"`},
+		{name: "delete", method: "DELETE", target: "/_static/apis/ids", wantStatus: 204},
+		{name: "serve deleted", method: "GET", target: "/ids/1", wantStatus: 404},
+		{name: "delete missing", method: "DELETE", target: "/_static/apis/ids", wantStatus: 404},
+		{name: "delete all", method: "DELETE", target: "/_static/apis", wantStatus: 204},
+		{name: "serve after deleting all", method: "GET", target: "/generated", wantStatus: 404},
+		{name: "patch is not allowed", method: "PATCH", target: "/_static/apis/ids", wantStatus: 405},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := serve(s, tt.method, tt.target, tt.body)
+			if status != tt.wantStatus {
+				t.Errorf("status = %d, want %d (body: %s)", status, tt.wantStatus, body)
+			}
+			if !strings.Contains(body, tt.wantBody) {
+				t.Errorf("body = %s, want %s", body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestRuntimeAPIPrecedence(t *testing.T) {
+	s := newTestServer(t, config.Config{}, apisTestFile)
+
+	// a runtime StaticAPI overriding the file StaticAPI with the same path
+	if status, body := serve(s, "PUT", "/_static/apis/same", runtimeAPI("/users/{id}", "same")); status != 201 {
+		t.Fatalf("status = %d, want 201 (body: %s)", status, body)
+	}
+	if _, body := serve(s, "GET", "/users/1", ""); body != "same" {
+		t.Errorf("body = %q, want the runtime StaticAPI", body)
+	}
+	serve(s, "DELETE", "/_static/apis/same", "")
+
+	// a runtime StaticAPI conflicting with the file StaticAPI is registered first and wins
+	if status, body := serve(s, "PUT", "/_static/apis/users", runtimeAPI("/users/{userId}", "runtime")); status != 201 {
+		t.Fatalf("status = %d, want 201 (body: %s)", status, body)
+	}
+	if _, body := serve(s, "GET", "/users/1", ""); body != "runtime" {
+		t.Errorf("body = %q, want the runtime StaticAPI", body)
+	}
+	if _, body := serve(s, "GET", "/orders", ""); body != "orders" {
+		t.Errorf("body = %q, want the unrelated file StaticAPI", body)
+	}
+
+	// the file StaticAPI is back once the runtime StaticAPI is deleted
+	serve(s, "DELETE", "/_static/apis/users", "")
+	if _, body := serve(s, "GET", "/users/1", ""); body != "file" {
+		t.Errorf("body = %q, want the file StaticAPI", body)
+	}
+}
+
+func TestRuntimeAPIsSurviveReloads(t *testing.T) {
+	s := newTestServer(t, config.Config{}, apisTestFile)
+	serve(s, "PUT", "/_static/apis/users", runtimeAPI("/users/{userId}", "runtime"))
+
+	reloaded := strings.Replace(apisTestFile, "body: orders", "body: reloaded", 1)
+	if err := os.WriteFile(s.cfg.StaticAPIsFile, []byte(reloaded), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.loadStaticAPIsFromFile(); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+
+	if _, body := serve(s, "GET", "/orders", ""); body != "reloaded" {
+		t.Errorf("body = %q, want the reloaded file StaticAPI", body)
+	}
+	if _, body := serve(s, "GET", "/users/1", ""); body != "runtime" {
+		t.Errorf("body = %q, want the runtime StaticAPI after the reload", body)
+	}
+	if _, body := serve(s, "GET", "/_static/apis/users", ""); !strings.Contains(body, `"path":"/users/{userId}"`) {
+		t.Errorf("body = %s, want the runtime StaticAPI", body)
+	}
+}
@@ -0,0 +1,142 @@
+package static
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Body encodings
+const (
+	EncodingBase64 = "base64"
+)
+
+// errBodyFileNotFound is returned for body files selected by path parameters that do not exist
+var errBodyFileNotFound = errors.New("body file not found")
+
+// validateBody checks that the response has at most one body source, a supported
+// encoding and, for body files, a path inside the body directory.
+func (r *ResponseConfig) validateBody() error {
+	if r.BodyFile != "" && r.Body != "" {
+		return errors.New("body and body-file are mutually exclusive")
+	}
+
+	switch r.BodyEncoding {
+	case "":
+	case EncodingBase64:
+		if r.BodyFile != "" {
+			return errors.New("body-encoding does not apply to body-file")
+		}
+		if _, err := base64.StdEncoding.DecodeString(r.Body); err != nil {
+			return fmt.Errorf("invalid base64 body: %w", err)
+		}
+	default:
+		return fmt.Errorf("invalid body-encoding: %s", r.BodyEncoding)
+	}
+
+	if r.BodyFile != "" && !filepath.IsLocal(r.BodyFile) {
+		return fmt.Errorf("body-file %q must be a relative path inside STATICAPIS_PATH", r.BodyFile)
+	}
+	return nil
+}
+
+// validateBodies checks the bodies of all responses of the method.
+func (m *MethodConfig) validateBodies() error {
+	responses := append([]ResponseConfig{m.DefaultResponse()}, m.Responses...)
+	responses = append(responses, m.Sequence...)
+	for _, response := range responses {
+		if err := response.validateBody(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadBodyFiles reads the body files without path parameters of all responses,
+// so they are served from memory and missing files are reported when loading.
+// Body files selected by path parameters are read for each request instead.
+func (e *StaticAPI) loadBodyFiles() error {
+	e.bodies = map[string][]byte{}
+	for _, method := range e.Methods {
+		responses := append([]ResponseConfig{method.DefaultResponse()}, method.Responses...)
+		responses = append(responses, method.Sequence...)
+		for _, response := range responses {
+			if response.BodyFile == "" || strings.Contains(response.BodyFile, "{") {
+				continue
+			}
+			if _, ok := e.bodies[response.BodyFile]; ok {
+				continue
+			}
+			data, err := os.ReadFile(filepath.Join(e.bodyDir, response.BodyFile))
+			if err != nil {
+				return fmt.Errorf("method %s %s: %w", method.Method, e.Path, err)
+			}
+			e.bodies[response.BodyFile] = data
+		}
+	}
+	return nil
+}
+
+// bodyFiles returns the paths of the body files read when loading the StaticAPI.
+func (e *StaticAPI) bodyFiles() []string {
+	files := make([]string, 0, len(e.bodies))
+	for name := range e.bodies {
+		files = append(files, filepath.Join(e.bodyDir, name))
+	}
+	return files
+}
+
+// body returns the body of response and the content type implied by its file extension, if any.
+// rendered is the body after path parameter expansion or templating.
+func (e *StaticAPI) body(response ResponseConfig, rendered string, params map[string]string) ([]byte, string, error) {
+	switch {
+	case response.BodyFile != "":
+		name := expandPathParams(response.BodyFile, params)
+		contentType := mime.TypeByExtension(filepath.Ext(name))
+		if data, ok := e.bodies[name]; ok {
+			return data, contentType, nil
+		}
+
+		// path parameters must not lead outside the body directory
+		if !filepath.IsLocal(name) {
+			return nil, "", errBodyFileNotFound
+		}
+		path := filepath.Join(e.bodyDir, name)
+		if info, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) || (err == nil && info.IsDir()) {
+			return nil, "", errBodyFileNotFound
+		}
+		data, err := os.ReadFile(path)
+		return data, contentType, err
+	case response.BodyEncoding == EncodingBase64:
+		data, err := base64.StdEncoding.DecodeString(rendered)
+		return data, "", err
+	}
+	return []byte(rendered), "", nil
+}
+
+// detectContentType returns the content type of a body without a Content-Type header:
+// the type implied by the extension of its file, JSON for valid JSON documents,
+// or the type sniffed from its first bytes.
+func detectContentType(body []byte, fileType string) string {
+	switch {
+	case fileType != "":
+		return fileType
+	case isJSONDocument(body):
+		return "application/json"
+	}
+	return http.DetectContentType(body)
+}
+
+// isJSONDocument reports whether body is a JSON object or array.
+func isJSONDocument(body []byte) bool {
+	trimmed := bytes.TrimSpace(body)
+	return len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') && json.Valid(trimmed)
+}
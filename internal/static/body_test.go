@@ -0,0 +1,191 @@
+package static
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/antonjah/static/internal/config"
+)
+
+// writeFiles writes files keyed by their slash separated path relative to dir.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestResponseBodies(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "config")
+	writeFiles(t, root, map[string]string{
+		"secret.txt":                   "secret",
+		"config/fixtures/users/1.json": `{"id": 1}`,
+		"config/assets/css/site.css":   "body {}",
+		"config/notes":                 "plain notes",
+	})
+
+	s := newTestServer(t, config.Config{StaticAPIsPath: dir}, `staticapis:
+- path: /users/{id}
+  methods:
+  - method: GET
+    status-code: 200
+    body-file: fixtures/users/{id}.json
+- path: /assets/{rest...}
+  methods:
+  - method: GET
+    status-code: 200
+    body-file: assets/{rest...}
+- path: /notes
+  methods:
+  - method: GET
+    status-code: 200
+    body-file: notes
+- path: /missing
+  methods:
+  - method: GET
+    status-code: 200
+    body-file: missing.json
+- path: /pixel.gif
+  methods:
+  - method: GET
+    status-code: 200
+    body: R0lGODlhAQABAAAAACw=
+    body-encoding: base64
+- path: /inline
+  methods:
+  - method: GET
+    status-code: 200
+    body: '{"ok": true}'
+  - method: POST
+    status-code: 200
+    body: '{"ok": true}'
+    headers:
+      content-type: text/plain
+`)
+
+	tests := []struct {
+		name            string
+		method          string
+		target          string
+		wantStatus      int
+		wantBody        string
+		wantContentType string
+	}{
+		{name: "body file by path parameter", target: "/users/1", wantStatus: http.StatusOK, wantBody: `{"id": 1}`, wantContentType: "application/json"},
+		{name: "missing body file", target: "/users/2", wantStatus: http.StatusNotFound},
+		{name: "body file in subdirectory", target: "/assets/css/site.css", wantStatus: http.StatusOK, wantBody: "body {}", wantContentType: "text/css; charset=utf-8"},
+		{name: "directory", target: "/assets/css", wantStatus: http.StatusNotFound},
+		{name: "escaped traversal", target: "/assets/..%2F..%2Fsecret.txt", wantStatus: http.StatusNotFound},
+		{name: "body file without extension", target: "/notes", wantStatus: http.StatusOK, wantBody: "plain notes", wantContentType: "text/plain; charset=utf-8"},
+		{name: "unreadable body file skips StaticAPI", target: "/missing", wantStatus: http.StatusNotFound},
+		{name: "base64 body", target: "/pixel.gif", wantStatus: http.StatusOK, wantBody: "GIF89a", wantContentType: "image/gif"},
+		{name: "inline JSON body", target: "/inline", wantStatus: http.StatusOK, wantBody: `{"ok": true}`, wantContentType: "application/json"},
+		{name: "content-type header wins", method: http.MethodPost, target: "/inline", wantStatus: http.StatusOK, wantContentType: "text/plain"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+
+			w := httptest.NewRecorder()
+			s.ServeHTTP(w, httptest.NewRequest(method, tt.target, nil))
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("body = %q, want it to contain %q", w.Body.String(), tt.wantBody)
+			}
+			if tt.wantContentType != "" && w.Header().Get("Content-Type") != tt.wantContentType {
+				t.Errorf("Content-Type = %q, want %q", w.Header().Get("Content-Type"), tt.wantContentType)
+			}
+		})
+	}
+}
+
+func TestRuntimeAPIBodyFile(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"fixtures/order.json": `{"id": 1}`})
+	s := newTestServer(t, config.Config{StaticAPIsPath: dir}, "staticapis: []\n")
+
+	status, body := serve(s, http.MethodPut, "/_static/apis/missing",
+		"path: /missing\nmethods:\n- method: GET\n  status-code: 200\n  body-file: fixtures/missing.json\n")
+	if status != http.StatusBadRequest || !strings.Contains(body, "invalid StaticAPI") {
+		t.Errorf("PUT with missing body file = %d %q, want 400", status, body)
+	}
+
+	status, _ = serve(s, http.MethodPut, "/_static/apis/order",
+		"path: /order\nmethods:\n- method: GET\n  status-code: 200\n  body-file: fixtures/order.json\n")
+	if status != http.StatusCreated {
+		t.Fatalf("PUT status = %d, want %d", status, http.StatusCreated)
+	}
+	if status, body := serve(s, http.MethodGet, "/order", ""); status != http.StatusOK || body != `{"id": 1}` {
+		t.Errorf("GET /order = %d %q, want the body file", status, body)
+	}
+}
+
+func TestValidateBody(t *testing.T) {
+	tests := []struct {
+		name     string
+		response ResponseConfig
+		wantErr  string
+	}{
+		{name: "inline body", response: ResponseConfig{Body: "hello"}},
+		{name: "body file", response: ResponseConfig{BodyFile: "fixtures/{id}.json"}},
+		{name: "base64 body", response: ResponseConfig{Body: "aGVsbG8=", BodyEncoding: EncodingBase64}},
+		{name: "body and body file", response: ResponseConfig{Body: "hello", BodyFile: "hello.txt"}, wantErr: "mutually exclusive"},
+		{name: "invalid base64", response: ResponseConfig{Body: "not base64!", BodyEncoding: EncodingBase64}, wantErr: "invalid base64 body"},
+		{name: "encoded body file", response: ResponseConfig{BodyFile: "hello.txt", BodyEncoding: EncodingBase64}, wantErr: "does not apply to body-file"},
+		{name: "unknown encoding", response: ResponseConfig{Body: "hello", BodyEncoding: "gzip"}, wantErr: "invalid body-encoding: gzip"},
+		{name: "absolute body file", response: ResponseConfig{BodyFile: "/etc/passwd"}, wantErr: "must be a relative path"},
+		{name: "body file outside directory", response: ResponseConfig{BodyFile: "../secret.txt"}, wantErr: "must be a relative path"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.response.validateBody()
+			if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDetectContentType(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		fileType string
+		want     string
+	}{
+		{name: "file type", body: `{"a": 1}`, fileType: "text/csv; charset=utf-8", want: "text/csv; charset=utf-8"},
+		{name: "JSON object", body: ` {"a": 1}`, want: "application/json"},
+		{name: "JSON array", body: "[1, 2]\n", want: "application/json"},
+		{name: "invalid JSON", body: `{"a": `, want: "text/plain; charset=utf-8"},
+		{name: "JSON scalar", body: `"a"`, want: "text/plain; charset=utf-8"},
+		{name: "HTML", body: "<!DOCTYPE html><html></html>", want: "text/html; charset=utf-8"},
+		{name: "binary", body: "\x89PNG\r\n\x1a\n", want: "image/png"},
+		{name: "empty", want: "text/plain; charset=utf-8"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detectContentType([]byte(tt.body), tt.fileType); got != tt.want {
+				t.Errorf("detectContentType() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package static
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+
+	staticv1alpha1 "github.com/antonjah/static/pkg/apis/static/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ResolveBodySources checks that the ConfigMap and Secret keys the bodies of obj are read
+// from can be read, the way the static service does when loading it.
+func ResolveBodySources(ctx context.Context, reader client.Reader, obj staticv1alpha1.StaticAPI) error {
+	staticAPI := convertToStaticAPI(obj)
+	return resolveBodySources(ctx, reader, obj, &staticAPI, map[string][]byte{})
+}
+
+// resolveBodySources reads the bodies obj references from ConfigMap and Secret keys into
+// staticAPI, the converted form of obj. The values read are added to bodies keyed by their
+// reference, so changes to the referenced objects change the configuration hash.
+func resolveBodySources(ctx context.Context, reader client.Reader, obj staticv1alpha1.StaticAPI, staticAPI *StaticAPI, bodies map[string][]byte) error {
+	var err error
+	for i, m := range obj.Spec.Methods {
+		method := &staticAPI.Methods[i]
+		if m.BodyFrom != nil {
+			if m.Body != "" {
+				return fmt.Errorf("method %s: body and bodyFrom are mutually exclusive", m.Method)
+			}
+			if method.Body, method.BodyEncoding, err = bodyFrom(ctx, reader, obj.Namespace, m.BodyFrom, bodies); err != nil {
+				return fmt.Errorf("method %s: %w", m.Method, err)
+			}
+		}
+		for j, r := range m.Responses {
+			if r.BodyFrom != nil {
+				if r.Body != "" {
+					return fmt.Errorf("response %d of method %s: body and bodyFrom are mutually exclusive", j, m.Method)
+				}
+				response := &method.Responses[j]
+				if response.Body, response.BodyEncoding, err = bodyFrom(ctx, reader, obj.Namespace, r.BodyFrom, bodies); err != nil {
+					return fmt.Errorf("response %d of method %s: %w", j, m.Method, err)
+				}
+			}
+		}
+		for j, r := range m.Sequence {
+			if r.BodyFrom != nil {
+				if r.Body != "" {
+					return fmt.Errorf("sequence response %d of method %s: body and bodyFrom are mutually exclusive", j, m.Method)
+				}
+				response := &method.Sequence[j]
+				if response.Body, response.BodyEncoding, err = bodyFrom(ctx, reader, obj.Namespace, r.BodyFrom, bodies); err != nil {
+					return fmt.Errorf("sequence response %d of method %s: %w", j, m.Method, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// bodyFrom returns the body read from the ConfigMap or Secret key selected by source and its
+// body encoding; values that are not valid UTF-8 are returned base64 encoded. Missing objects
+// or keys are an error unless the selector is optional, in which case the body is empty.
+// Objects without the body source label are never read.
+func bodyFrom(ctx context.Context, reader client.Reader, namespace string, source *staticv1alpha1.BodySource, bodies map[string][]byte) (string, string, error) {
+	var ref string
+	var data []byte
+	var found bool
+	var optional *bool
+	var err error
+
+	switch {
+	case source.ConfigMapKeyRef != nil && source.SecretKeyRef != nil:
+		return "", "", errors.New("bodyFrom must reference either a ConfigMap or a Secret key")
+	case source.ConfigMapKeyRef != nil:
+		selector := source.ConfigMapKeyRef
+		ref = fmt.Sprintf("configmap %s/%s key %s", namespace, selector.Name, selector.Key)
+		optional = selector.Optional
+		if data, found = bodies[ref]; !found {
+			data, found, err = configMapValue(ctx, reader, namespace, selector)
+		}
+	case source.SecretKeyRef != nil:
+		selector := source.SecretKeyRef
+		ref = fmt.Sprintf("secret %s/%s key %s", namespace, selector.Name, selector.Key)
+		optional = selector.Optional
+		if data, found = bodies[ref]; !found {
+			data, found, err = secretValue(ctx, reader, namespace, selector)
+		}
+	default:
+		return "", "", errors.New("bodyFrom must reference a ConfigMap or a Secret key")
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read %s: %w", ref, err)
+	}
+
+	if !found {
+		if optional != nil && *optional {
+			return "", "", nil
+		}
+		return "", "", fmt.Errorf("%s not found or not labeled %s=true", ref, staticv1alpha1.BodySourceLabel)
+	}
+	bodies[ref] = data
+
+	if !utf8.Valid(data) {
+		return base64.StdEncoding.EncodeToString(data), EncodingBase64, nil
+	}
+	return string(data), "", nil
+}
+
+// configMapValue returns the value of a ConfigMap key and whether it exists.
+func configMapValue(ctx context.Context, reader client.Reader, namespace string, selector *corev1.ConfigMapKeySelector) ([]byte, bool, error) {
+	var configMap corev1.ConfigMap
+	if err := reader.Get(ctx, client.ObjectKey{Namespace: namespace, Name: selector.Name}, &configMap); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	if err := checkBodySource(&configMap); err != nil {
+		return nil, false, err
+	}
+
+	if value, ok := configMap.Data[selector.Key]; ok {
+		return []byte(value), true, nil
+	}
+	value, ok := configMap.BinaryData[selector.Key]
+	return value, ok, nil
+}
+
+// secretValue returns the value of a Secret key and whether it exists.
+func secretValue(ctx context.Context, reader client.Reader, namespace string, selector *corev1.SecretKeySelector) ([]byte, bool, error) {
+	var secret corev1.Secret
+	if err := reader.Get(ctx, client.ObjectKey{Namespace: namespace, Name: selector.Name}, &secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	if err := checkBodySource(&secret); err != nil {
+		return nil, false, err
+	}
+
+	value, ok := secret.Data[selector.Key]
+	return value, ok, nil
+}
+
+// checkBodySource returns an error unless obj opted in to be read from with the body source label.
+func checkBodySource(obj client.Object) error {
+	if obj.GetLabels()[staticv1alpha1.BodySourceLabel] != "true" {
+		return fmt.Errorf("not labeled %s=true", staticv1alpha1.BodySourceLabel)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package static
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	staticv1alpha1 "github.com/antonjah/static/pkg/apis/static/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestBodyFrom(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	labeled := map[string]string{staticv1alpha1.BodySourceLabel: "true"}
+	reader := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "fixtures", Labels: labeled},
+			Data:       map[string]string{"users.json": `[{"id":1}]`},
+		},
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "unlabeled"},
+			Data:       map[string]string{"users.json": `[{"id":1}]`},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "tokens", Labels: labeled},
+			Data:       map[string][]byte{"token": []byte("secret"), "image": {0xff, 0xd8}},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "static-operator-webhook-cert"},
+			Data:       map[string][]byte{"tls.key": []byte("private key")},
+		},
+	).Build()
+
+	optional := true
+	configMapKey := func(name, key string) *staticv1alpha1.BodySource {
+		return &staticv1alpha1.BodySource{ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: name}, Key: key,
+		}}
+	}
+	secretKey := func(name, key string) *staticv1alpha1.BodySource {
+		return &staticv1alpha1.BodySource{SecretKeyRef: &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: name}, Key: key,
+		}}
+	}
+	optionalKey := configMapKey("missing", "users.json")
+	optionalKey.ConfigMapKeyRef.Optional = &optional
+
+	tests := []struct {
+		name         string
+		source       *staticv1alpha1.BodySource
+		wantBody     string
+		wantEncoding string
+		wantErr      string
+	}{
+		{name: "configmap key", source: configMapKey("fixtures", "users.json"), wantBody: `[{"id":1}]`},
+		{name: "secret key", source: secretKey("tokens", "token"), wantBody: "secret"},
+		{name: "binary secret key", source: secretKey("tokens", "image"), wantBody: "/9g=", wantEncoding: EncodingBase64},
+		{name: "missing key", source: configMapKey("fixtures", "orders.json"), wantErr: "not found"},
+		{name: "missing optional configmap", source: optionalKey},
+		{name: "unlabeled configmap", source: configMapKey("unlabeled", "users.json"), wantErr: "not labeled"},
+		{name: "unlabeled secret", source: secretKey("static-operator-webhook-cert", "tls.key"), wantErr: "not labeled"},
+		{name: "no reference", source: &staticv1alpha1.BodySource{}, wantErr: "must reference"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bodies := map[string][]byte{}
+			body, encoding, err := bodyFrom(context.Background(), reader, "default", tt.source, bodies)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("bodyFrom error = %v, want error containing %q", err, tt.wantErr)
+				}
+				if len(bodies) > 0 {
+					t.Errorf("bodies = %v, want none read", bodies)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("bodyFrom: %v", err)
+			}
+			if body != tt.wantBody || encoding != tt.wantEncoding {
+				t.Errorf("bodyFrom = %q, %q, want %q, %q", body, encoding, tt.wantBody, tt.wantEncoding)
+			}
+		})
+	}
+}
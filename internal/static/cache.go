@@ -0,0 +1,70 @@
+package static
+
+import (
+	"container/list"
+	"sync"
+)
+
+// cache holds up to size values keyed by their source, evicting the least recently used
+// value when full, so that expressions of runtime stubs cannot grow it without bound.
+type cache[V any] struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List // of *cacheEntry[V], most recently used first
+	entries map[string]*list.Element
+}
+
+// cacheEntry is a value held in a cache with its key
+type cacheEntry[V any] struct {
+	key   string
+	value V
+}
+
+// newCache returns an empty cache holding up to size values.
+func newCache[V any](size int) *cache[V] {
+	return &cache[V]{
+		size:    size,
+		order:   list.New(),
+		entries: map[string]*list.Element{},
+	}
+}
+
+// Load returns the value stored for key, if any.
+func (c *cache[V]) Load(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	c.order.MoveToFront(element)
+	return element.Value.(*cacheEntry[V]).value, true
+}
+
+// Store stores value for key, evicting the least recently used value if the cache is full.
+func (c *cache[V]) Store(key string, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		element.Value.(*cacheEntry[V]).value = value
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&cacheEntry[V]{key: key, value: value})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry[V]).key)
+	}
+}
+
+// Len returns the number of values in the cache.
+func (c *cache[V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
@@ -0,0 +1,46 @@
+package static
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestCache(t *testing.T) {
+	c := newCache[int](2)
+	c.Store("a", 1)
+	c.Store("b", 2)
+
+	// using "a" makes "b" the least recently used value
+	if v, ok := c.Load("a"); !ok || v != 1 {
+		t.Fatalf(`Load("a") = %v, %v, want 1, true`, v, ok)
+	}
+	c.Store("c", 3)
+
+	if _, ok := c.Load("b"); ok {
+		t.Error(`Load("b") found an evicted value`)
+	}
+	for key, want := range map[string]int{"a": 1, "c": 3} {
+		if v, ok := c.Load(key); !ok || v != want {
+			t.Errorf("Load(%q) = %v, %v, want %v, true", key, v, ok, want)
+		}
+	}
+
+	c.Store("c", 4)
+	if v, _ := c.Load("c"); v != 4 {
+		t.Errorf(`Load("c") = %v, want 4`, v)
+	}
+	if c.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", c.Len())
+	}
+}
+
+func TestRegexpCacheBounded(t *testing.T) {
+	for i := range regexpCache.size + 10 {
+		if _, err := compileRegexp("^" + strconv.Itoa(i) + "$"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if regexpCache.Len() > regexpCache.size {
+		t.Errorf("regexpCache holds %d expressions, want at most %d", regexpCache.Len(), regexpCache.size)
+	}
+}
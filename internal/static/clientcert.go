@@ -0,0 +1,110 @@
+package static
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ClientCertificate is the identity of the verified TLS client certificate of a request
+type ClientCertificate struct {
+	CommonName          string   `json:"commonName"`
+	OrganizationalUnits []string `json:"organizationalUnits,omitempty"`
+	// SANs are the DNS names, email addresses, IP addresses and URIs of the certificate
+	SANs []string `json:"sans,omitempty"`
+	// Fingerprint is the hex encoded SHA-256 digest of the certificate
+	Fingerprint string `json:"fingerprint"`
+}
+
+// clientCertificate returns the identity of the client certificate verified during the TLS
+// handshake of req, or nil if the client presented none or it was not verified.
+func clientCertificate(req *http.Request) *ClientCertificate {
+	if req.TLS == nil || len(req.TLS.VerifiedChains) == 0 || len(req.TLS.PeerCertificates) == 0 {
+		return nil
+	}
+	return newClientCertificate(req.TLS.PeerCertificates[0])
+}
+
+func newClientCertificate(certificate *x509.Certificate) *ClientCertificate {
+	sans := append([]string{}, certificate.DNSNames...)
+	sans = append(sans, certificate.EmailAddresses...)
+	for _, ip := range certificate.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+	for _, uri := range certificate.URIs {
+		sans = append(sans, uri.String())
+	}
+
+	fingerprint := sha256.Sum256(certificate.Raw)
+	return &ClientCertificate{
+		CommonName:          certificate.Subject.CommonName,
+		OrganizationalUnits: certificate.Subject.OrganizationalUnit,
+		SANs:                sans,
+		Fingerprint:         hex.EncodeToString(fingerprint[:]),
+	}
+}
+
+// Matches reports whether the verified client certificate of req satisfies all rules.
+// Without rules any verified client certificate matches.
+func (c *ClientCertificateMatch) Matches(req *http.Request) bool {
+	identity := clientCertificate(req)
+	if identity == nil {
+		return false
+	}
+
+	if c.CommonName != nil && !c.CommonName.Matches(identity.CommonName) {
+		return false
+	}
+	if c.OrganizationalUnit != nil && !matchesAny(c.OrganizationalUnit, identity.OrganizationalUnits) {
+		return false
+	}
+	if c.SAN != nil && !matchesAny(c.SAN, identity.SANs) {
+		return false
+	}
+	if c.Fingerprint != "" && normalizeFingerprint(c.Fingerprint) != identity.Fingerprint {
+		return false
+	}
+	return true
+}
+
+// Validate checks that the regular expressions compile and the fingerprint is a SHA-256 digest.
+func (c *ClientCertificateMatch) Validate() error {
+	for kind, match := range map[string]*ValueMatch{
+		"common name":         c.CommonName,
+		"organizational unit": c.OrganizationalUnit,
+		"SAN":                 c.SAN,
+	} {
+		if match == nil {
+			continue
+		}
+		if err := match.Validate(); err != nil {
+			return fmt.Errorf("invalid %s match: %w", kind, err)
+		}
+	}
+
+	if c.Fingerprint != "" {
+		if digest, err := hex.DecodeString(normalizeFingerprint(c.Fingerprint)); err != nil || len(digest) != sha256.Size {
+			return fmt.Errorf("invalid fingerprint %q: expected a hex encoded SHA-256 digest", c.Fingerprint)
+		}
+	}
+	return nil
+}
+
+// matchesAny reports whether any of values satisfies match.
+func matchesAny(match *ValueMatch, values []string) bool {
+	for _, value := range values {
+		if match.Matches(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeFingerprint lowercases a hex fingerprint and strips the colons some tools
+// separate its bytes with, e.g. "AB:CD:..." as printed by openssl.
+func normalizeFingerprint(fingerprint string) string {
+	return strings.ToLower(strings.ReplaceAll(fingerprint, ":", ""))
+}
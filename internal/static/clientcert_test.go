@@ -0,0 +1,200 @@
+package static
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestClientCertificate returns a client certificate of alice issued by a test CA and
+// its verified chain.
+func newTestClientCertificate(t *testing.T) (*x509.Certificate, []*x509.Certificate) {
+	t.Helper()
+	ca, _ := newTestCA(t, "client-ca")
+	certificatePEM, _, err := ca.IssueClient("alice", []string{"qa", "ops"},
+		[]string{"alice.example", "alice@example.com", "10.0.0.1", "spiffe://example.org/alice"}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ := pem.Decode(certificatePEM)
+	certificate, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return certificate, []*x509.Certificate{certificate, ca.Certificate}
+}
+
+// requestWithCertificate returns a request presenting certificate, verified if chain is set.
+func requestWithCertificate(certificate *x509.Certificate, chain []*x509.Certificate) *http.Request {
+	req := httptest.NewRequest("GET", "/orders", nil)
+	if certificate == nil {
+		return req
+	}
+	req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{certificate}}
+	if chain != nil {
+		req.TLS.VerifiedChains = [][]*x509.Certificate{chain}
+	}
+	return req
+}
+
+func TestClientCertificate(t *testing.T) {
+	certificate, chain := newTestClientCertificate(t)
+	digest := sha256.Sum256(certificate.Raw)
+
+	tests := []struct {
+		name string
+		req  *http.Request
+		want *ClientCertificate
+	}{
+		{
+			name: "verified",
+			req:  requestWithCertificate(certificate, chain),
+			want: &ClientCertificate{
+				CommonName:          "alice",
+				OrganizationalUnits: []string{"qa", "ops"},
+				SANs:                []string{"alice.example", "alice@example.com", "10.0.0.1", "spiffe://example.org/alice"},
+				Fingerprint:         hex.EncodeToString(digest[:]),
+			},
+		},
+		{name: "not verified", req: requestWithCertificate(certificate, nil)},
+		{name: "without TLS", req: requestWithCertificate(nil, nil)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := clientCertificate(tt.req); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("clientCertificate = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientCertificateMatch(t *testing.T) {
+	certificate, chain := newTestClientCertificate(t)
+	digest := sha256.Sum256(certificate.Raw)
+	fingerprint := hex.EncodeToString(digest[:])
+
+	// openssl prints fingerprints in upper case with colons between the bytes
+	var opensslFingerprint []string
+	for i := 0; i < len(fingerprint); i += 2 {
+		opensslFingerprint = append(opensslFingerprint, strings.ToUpper(fingerprint[i:i+2]))
+	}
+
+	verified := requestWithCertificate(certificate, chain)
+
+	tests := []struct {
+		name  string
+		match ClientCertificateMatch
+		req   *http.Request
+		want  bool
+	}{
+		{name: "any verified certificate", req: verified, want: true},
+		{name: "common name", match: ClientCertificateMatch{CommonName: &ValueMatch{Equals: "alice"}}, req: verified, want: true},
+		{name: "common name regex", match: ClientCertificateMatch{CommonName: &ValueMatch{Regex: "^ali"}}, req: verified, want: true},
+		{name: "other common name", match: ClientCertificateMatch{CommonName: &ValueMatch{Equals: "bob"}}, req: verified},
+		{name: "any organizational unit", match: ClientCertificateMatch{OrganizationalUnit: &ValueMatch{Equals: "ops"}}, req: verified, want: true},
+		{name: "other organizational unit", match: ClientCertificateMatch{OrganizationalUnit: &ValueMatch{Equals: "dev"}}, req: verified},
+		{name: "DNS SAN", match: ClientCertificateMatch{SAN: &ValueMatch{Equals: "alice.example"}}, req: verified, want: true},
+		{name: "email SAN", match: ClientCertificateMatch{SAN: &ValueMatch{Equals: "alice@example.com"}}, req: verified, want: true},
+		{name: "IP SAN", match: ClientCertificateMatch{SAN: &ValueMatch{Equals: "10.0.0.1"}}, req: verified, want: true},
+		{name: "URI SAN", match: ClientCertificateMatch{SAN: &ValueMatch{Regex: "^spiffe://example.org/"}}, req: verified, want: true},
+		{name: "other SAN", match: ClientCertificateMatch{SAN: &ValueMatch{Equals: "bob.example"}}, req: verified},
+		{name: "fingerprint", match: ClientCertificateMatch{Fingerprint: fingerprint}, req: verified, want: true},
+		{name: "openssl fingerprint", match: ClientCertificateMatch{Fingerprint: strings.Join(opensslFingerprint, ":")}, req: verified, want: true},
+		{name: "other fingerprint", match: ClientCertificateMatch{Fingerprint: strings.Repeat("ab", sha256.Size)}, req: verified},
+		{
+			name: "all rules",
+			match: ClientCertificateMatch{
+				CommonName:         &ValueMatch{Equals: "alice"},
+				OrganizationalUnit: &ValueMatch{Equals: "qa"},
+				SAN:                &ValueMatch{Equals: "alice.example"},
+				Fingerprint:        fingerprint,
+			},
+			req:  verified,
+			want: true,
+		},
+		{
+			name: "one rule failing",
+			match: ClientCertificateMatch{
+				CommonName:         &ValueMatch{Equals: "alice"},
+				OrganizationalUnit: &ValueMatch{Equals: "dev"},
+			},
+			req: verified,
+		},
+		{name: "certificate not verified", match: ClientCertificateMatch{CommonName: &ValueMatch{Equals: "alice"}}, req: requestWithCertificate(certificate, nil)},
+		{name: "no certificate", req: requestWithCertificate(nil, nil)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.match.Matches(tt.req); got != tt.want {
+				t.Errorf("Matches = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientCertificateMatchValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		match   ClientCertificateMatch
+		wantErr string
+	}{
+		{name: "empty"},
+		{name: "fingerprint", match: ClientCertificateMatch{Fingerprint: strings.Repeat("AB:", sha256.Size-1) + "AB"}},
+		{name: "invalid regex", match: ClientCertificateMatch{SAN: &ValueMatch{Regex: "("}}, wantErr: "invalid SAN match"},
+		{name: "short fingerprint", match: ClientCertificateMatch{Fingerprint: "abcd"}, wantErr: "invalid fingerprint"},
+		{name: "non-hex fingerprint", match: ClientCertificateMatch{Fingerprint: strings.Repeat("zz", sha256.Size)}, wantErr: "invalid fingerprint"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.match.Validate()
+			if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestClientCertificateResponses(t *testing.T) {
+	certificate, chain := newTestClientCertificate(t)
+	method := MethodConfig{
+		Method:     "GET",
+		StatusCode: 403,
+		Responses: []ResponseConfig{{
+			Match:      MatchConfig{ClientCertificate: &ClientCertificateMatch{OrganizationalUnit: &ValueMatch{Equals: "qa"}}},
+			StatusCode: 200,
+		}},
+	}
+
+	tests := []struct {
+		name string
+		req  *http.Request
+		want int
+	}{
+		{name: "matching client", req: requestWithCertificate(certificate, chain), want: 200},
+		{name: "unverified client", req: requestWithCertificate(certificate, nil), want: 403},
+		{name: "anonymous client", req: requestWithCertificate(nil, nil), want: 403},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, ok := method.Match(tt.req)
+			if !ok {
+				response = method.DefaultResponse()
+			}
+			if response.StatusCode != tt.want {
+				t.Errorf("status code = %d, want %d", response.StatusCode, tt.want)
+			}
+		})
+	}
+}
@@ -15,12 +15,36 @@ type StaticAPIs struct {
 }
 
 type StaticAPI struct {
+	Name    string         `yaml:"name,omitempty"`
+	Host    string         `yaml:"host,omitempty"`
 	Path    string         `yaml:"path"`
 	Methods []MethodConfig `yaml:"methods"`
 
-	SupportedMethods SupportedMethods
+	SupportedMethods SupportedMethods `yaml:"-"`
+
+	// source is where the StaticAPI was loaded from, see the source constants
+	source string
+
+	// sequences holds the positions of response sequences, shared with the Server
+	sequences *sequences
+
+	// validation holds the request validation settings, shared with the Server
+	validation *validation
+
+	// bodyDir is the directory body files are read from
+	bodyDir string
+
+	// bodies holds the contents of the body files read when loading, keyed by file name
+	bodies map[string][]byte
 }
 
+// Sources of StaticAPIs
+const (
+	sourceFile       = "file"
+	sourceKubernetes = "kubernetes"
+	sourceRuntime    = "runtime"
+)
+
 func (e *StaticAPI) MethodFromRequest(req *http.Request) MethodConfig {
 	for _, method := range e.Methods {
 		if strings.Compare(strings.ToLower(method.Method), strings.ToLower(req.Method)) == 0 {
@@ -30,23 +54,124 @@ func (e *StaticAPI) MethodFromRequest(req *http.Request) MethodConfig {
 	return MethodConfig{}
 }
 
+// Pattern returns the pattern the StaticAPI is registered with on the mux.
+func (e *StaticAPI) Pattern() string {
+	return Pattern(e.Host, e.Path)
+}
+
 func (e *StaticAPI) SetSupported() {
 	for _, method := range e.Methods {
 		e.SupportedMethods = append(e.SupportedMethods, method.Method)
 	}
 }
 
+// Validate checks every StaticAPI, and that the paths of no two of them conflict.
+func (s *StaticAPIs) Validate() error {
+	var errs []error
+	var valid []StaticAPI
+	for _, staticAPI := range s.StaticAPIs {
+		if err := staticAPI.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", staticAPI.describe(), err))
+			continue
+		}
+		if err := staticAPI.conflict(valid); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		valid = append(valid, staticAPI)
+	}
+	return errors.Join(errs...)
+}
+
+// conflict returns an error naming the first of staticAPIs whose pattern conflicts with the
+// pattern of e: both match the same requests, but neither is more specific than the other.
+func (e *StaticAPI) conflict(staticAPIs []StaticAPI) error {
+	for _, other := range staticAPIs {
+		if conflicting(e.Pattern(), other.Pattern()) {
+			return fmt.Errorf("%s conflicts with %s", e.describe(), other.describe())
+		}
+	}
+	return nil
+}
+
+// describe returns the name and pattern of e for messages, e.g. `StaticAPI "users" (/users/{id})`.
+func (e *StaticAPI) describe() string {
+	if e.Name == "" {
+		return "StaticAPI " + e.Pattern()
+	}
+	return fmt.Sprintf("StaticAPI %q (%s)", e.Name, e.Pattern())
+}
+
 func (e *StaticAPI) Validate() error {
 	// validate path
 	if e.Path == "" {
 		return errors.New("missing path")
 	}
+	if err := validatePath(e.Path); err != nil {
+		return err
+	}
+	if err := validateHost(e.Host); err != nil {
+		return err
+	}
 
 	// validate status code for methods
+	seen := map[string]bool{}
 	for _, method := range e.Methods {
+		name := strings.ToUpper(method.Method)
+		if seen[name] {
+			return fmt.Errorf("duplicate method %s for %s", name, e.Path)
+		}
+		seen[name] = true
+
 		if method.StatusCode < 100 || method.StatusCode > 599 {
 			return fmt.Errorf("invalid status-code for method %s: %d", e.Path, method.StatusCode)
 		}
+
+		// validate response variants
+		for i, response := range method.Responses {
+			if response.StatusCode < 100 || response.StatusCode > 599 {
+				return fmt.Errorf("invalid status-code for response %d of method %s %s: %d", i, method.Method, e.Path, response.StatusCode)
+			}
+			if err := response.Match.Validate(); err != nil {
+				return fmt.Errorf("response %d of method %s %s: %w", i, method.Method, e.Path, err)
+			}
+		}
+
+		// validate faults
+		if method.Fault != nil {
+			if err := method.Fault.Validate(); err != nil {
+				return fmt.Errorf("method %s %s: %w", method.Method, e.Path, err)
+			}
+		}
+
+		// validate request validation
+		if method.Validation != nil {
+			if err := method.Validation.Validate(); err != nil {
+				return fmt.Errorf("method %s %s: %w", method.Method, e.Path, err)
+			}
+		}
+
+		// validate header names
+		if err := method.validateHeaders(); err != nil {
+			return fmt.Errorf("method %s %s: %w", method.Method, e.Path, err)
+		}
+
+		// validate bodies
+		if err := method.validateBodies(); err != nil {
+			return fmt.Errorf("method %s %s: %w", method.Method, e.Path, err)
+		}
+
+		// validate response sequence
+		if err := method.validateSequence(); err != nil {
+			return fmt.Errorf("method %s %s: %w", method.Method, e.Path, err)
+		}
+
+		// validate templates
+		if method.Template {
+			if err := method.validateTemplates(); err != nil {
+				return fmt.Errorf("method %s %s: %w", method.Method, e.Path, err)
+			}
+		}
 	}
 
 	return nil
@@ -62,16 +187,56 @@ func (e *StaticAPI) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	// get the requested method
 	method := e.MethodFromRequest(req)
 
+	// reject requests violating the OpenAPI document or the schemas of the method
+	if !e.validateRequest(w, &method, req) {
+		return
+	}
+
+	// inject delay, abort and error faults
+	if method.Fault != nil && method.Fault.inject(w, req) {
+		return
+	}
+
+	// pick the response for the request
+	response := e.response(&method, req)
+
+	// render body and header values for the request
+	params := e.PathParams(req)
+	rendered, headers, err := method.render(response, req, params)
+	if err != nil {
+		zap.L().Error("failed to render response", zap.String("path", e.Path), zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	// read the body from its file or decode it
+	body, fileType, err := e.body(response, rendered, params)
+	if errors.Is(err, errBodyFileNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		zap.L().Error("failed to read response body", zap.String("path", e.Path), zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
 	// append header(s)
-	for key, val := range method.Headers {
+	for key, val := range headers {
 		w.Header().Add(key, val)
 	}
+	if w.Header().Get("Content-Type") == "" && len(body) > 0 {
+		w.Header().Set("Content-Type", detectContentType(body, fileType))
+	}
 
-	// write status code
-	w.WriteHeader(method.StatusCode)
-
-	// write body
-	if _, err := w.Write([]byte(method.Body)); err != nil {
+	// write status code and body, stalling or truncating it if configured
+	if method.Fault != nil {
+		err = method.Fault.writeBody(w, req, response.StatusCode, body)
+	} else {
+		w.WriteHeader(response.StatusCode)
+		_, err = w.Write(body)
+	}
+	if err != nil {
 		zap.L().Error("failed to write response", zap.Error(err))
 	}
 }
@@ -0,0 +1,112 @@
+package static
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestStaticAPIsValidate(t *testing.T) {
+	newAPI := func(name, path string) StaticAPI {
+		return StaticAPI{Name: name, Path: path, Methods: []MethodConfig{{Method: "GET", StatusCode: 200}}}
+	}
+
+	tests := []struct {
+		name       string
+		staticAPIs []StaticAPI
+		wantErrs   []string
+	}{
+		{
+			name:       "valid",
+			staticAPIs: []StaticAPI{newAPI("users", "/users/{id}"), newAPI("me", "/users/me"), newAPI("files", "/files/{rest...}")},
+		},
+		{
+			name:       "conflicting paths",
+			staticAPIs: []StaticAPI{newAPI("users", "/users/{id}"), newAPI("names", "/users/{name}")},
+			wantErrs:   []string{`StaticAPI "names" (/users/{name}) conflicts with StaticAPI "users" (/users/{id})`},
+		},
+		{
+			name:       "conflicting paths without names",
+			staticAPIs: []StaticAPI{newAPI("", "/{kind}/1"), newAPI("", "/users/{id}")},
+			wantErrs:   []string{"StaticAPI /users/{id} conflicts with StaticAPI /{kind}/1"},
+		},
+		{
+			name:       "invalid StaticAPIs do not conflict",
+			staticAPIs: []StaticAPI{newAPI("invalid", "/users/{id}/{id}"), newAPI("users", "/users/{id}/{name}")},
+			wantErrs:   []string{`StaticAPI "invalid" (/users/{id}/{id}): path "/users/{id}/{id}": duplicate wildcard name "id"`},
+		},
+		{
+			name: "every error",
+			staticAPIs: []StaticAPI{
+				newAPI("users", "/users/{id}"),
+				newAPI("names", "/users/{name}"),
+				{Name: "status", Path: "/status", Methods: []MethodConfig{{Method: "GET", StatusCode: 600}}},
+			},
+			wantErrs: []string{`StaticAPI "names" (/users/{name}) conflicts with`, `StaticAPI "status" (/status): invalid status-code`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := (&StaticAPIs{StaticAPIs: tt.staticAPIs}).Validate()
+			if len(tt.wantErrs) == 0 && err != nil {
+				t.Fatalf("error = %v, want none", err)
+			}
+			for _, want := range tt.wantErrs {
+				if err == nil || !strings.Contains(err.Error(), want) {
+					t.Errorf("error = %v, want %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestServeHTTPExpandParams(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     MethodConfig
+		wantBody   string
+		wantHeader string
+	}{
+		{
+			name:       "disabled by default",
+			method:     MethodConfig{Body: `{"id": "{id}", "path": "{rest...}"}`, Headers: map[string]string{"x-id": "{id}"}},
+			wantBody:   `{"id": "{id}", "path": "{rest...}"}`,
+			wantHeader: "{id}",
+		},
+		{
+			name:       "enabled",
+			method:     MethodConfig{Body: `{"id": "{id}", "path": "{rest...}"}`, Headers: map[string]string{"x-id": "{id}"}, ExpandParams: true},
+			wantBody:   `{"id": "42", "path": "a/b"}`,
+			wantHeader: "42",
+		},
+		{
+			name:       "undeclared names are left alone",
+			method:     MethodConfig{Body: `{"id": "{id}", "other": "{name}"}`, Headers: map[string]string{"x-id": "{name}"}, ExpandParams: true},
+			wantBody:   `{"id": "42", "other": "{name}"}`,
+			wantHeader: "{name}",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.method.Method = "GET"
+			tt.method.StatusCode = 200
+			staticAPI := &StaticAPI{Path: "/users/{id}/{rest...}", Methods: []MethodConfig{tt.method}}
+			staticAPI.SetSupported()
+
+			mux := http.NewServeMux()
+			mux.Handle(staticAPI.Path, staticAPI)
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest("GET", "/users/42/a/b", nil))
+
+			if w.Body.String() != tt.wantBody {
+				t.Errorf("body = %q, want %q", w.Body, tt.wantBody)
+			}
+			if got := w.Header().Get("x-id"); got != tt.wantHeader {
+				t.Errorf("header = %q, want %q", got, tt.wantHeader)
+			}
+		})
+	}
+}
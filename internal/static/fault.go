@@ -0,0 +1,235 @@
+package static
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+// Delay distributions
+const (
+	DistributionUniform   = "uniform"
+	DistributionNormal    = "normal"
+	DistributionLogNormal = "lognormal"
+)
+
+// FaultConfig injects latency and failures into the responses of a method.
+// Percentages are in the range 0-100.
+type FaultConfig struct {
+	Delay *DelayConfig `yaml:"delay,omitempty" json:"delay,omitempty"`
+
+	// ErrorPercent of requests are answered with ErrorStatus (default: 500) instead
+	ErrorPercent int `yaml:"error-percent,omitempty" json:"errorPercent,omitempty"`
+	ErrorStatus  int `yaml:"error-status,omitempty" json:"errorStatus,omitempty"`
+
+	// AbortPercent of requests get their connection closed without a response
+	AbortPercent int `yaml:"abort-percent,omitempty" json:"abortPercent,omitempty"`
+
+	// TruncatePercent of responses get their connection closed halfway through the body
+	TruncatePercent int `yaml:"truncate-percent,omitempty" json:"truncatePercent,omitempty"`
+
+	// Stall pauses for the given duration halfway through the body
+	Stall Duration `yaml:"stall,omitempty" json:"stall,omitempty"`
+}
+
+// DelayConfig delays responses by Fixed plus a duration sampled from Distribution:
+// "uniform" between Min and Max, or "normal"/"lognormal" with Mean and StdDev.
+type DelayConfig struct {
+	Fixed        Duration `yaml:"fixed,omitempty" json:"fixed,omitempty"`
+	Distribution string   `yaml:"distribution,omitempty" json:"distribution,omitempty"`
+	Min          Duration `yaml:"min,omitempty" json:"min,omitempty"`
+	Max          Duration `yaml:"max,omitempty" json:"max,omitempty"`
+	Mean         Duration `yaml:"mean,omitempty" json:"mean,omitempty"`
+	StdDev       Duration `yaml:"stddev,omitempty" json:"stddev,omitempty"`
+}
+
+// Duration is a time.Duration written as a string such as "250ms" in configuration
+type Duration time.Duration
+
+// UnmarshalYAML parses a duration string such as "1.5s".
+func (d *Duration) UnmarshalYAML(node *yaml.Node) error {
+	parsed, err := time.ParseDuration(node.Value)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q: %w", node.Value, err)
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// MarshalYAML returns the duration as a string.
+func (d Duration) MarshalYAML() (any, error) {
+	return time.Duration(d).String(), nil
+}
+
+// MarshalJSON returns the duration as a JSON string.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// Validate checks the percentages, error status and delay settings.
+func (f *FaultConfig) Validate() error {
+	for name, percent := range map[string]int{
+		"error-percent":    f.ErrorPercent,
+		"abort-percent":    f.AbortPercent,
+		"truncate-percent": f.TruncatePercent,
+	} {
+		if percent < 0 || percent > 100 {
+			return fmt.Errorf("invalid %s: %d, must be between 0 and 100", name, percent)
+		}
+	}
+
+	if f.ErrorStatus != 0 && (f.ErrorStatus < 100 || f.ErrorStatus > 599) {
+		return fmt.Errorf("invalid error-status: %d", f.ErrorStatus)
+	}
+
+	if f.Stall < 0 {
+		return fmt.Errorf("invalid stall: %s", time.Duration(f.Stall))
+	}
+
+	if f.Delay != nil {
+		return f.Delay.Validate()
+	}
+	return nil
+}
+
+// Validate checks the distribution and its parameters.
+func (d *DelayConfig) Validate() error {
+	if d.Fixed < 0 || d.Min < 0 || d.Max < 0 || d.Mean < 0 || d.StdDev < 0 {
+		return fmt.Errorf("invalid delay: durations must not be negative")
+	}
+
+	switch d.Distribution {
+	case "", DistributionNormal:
+	case DistributionUniform:
+		if d.Max < d.Min {
+			return fmt.Errorf("invalid delay: max (%s) is less than min (%s)", time.Duration(d.Max), time.Duration(d.Min))
+		}
+	case DistributionLogNormal:
+		if d.Mean == 0 {
+			return fmt.Errorf("invalid delay: lognormal distribution requires a mean")
+		}
+	default:
+		return fmt.Errorf("invalid delay distribution %q, must be one of %s, %s or %s",
+			d.Distribution, DistributionUniform, DistributionNormal, DistributionLogNormal)
+	}
+	return nil
+}
+
+// Sample returns a delay drawn from the configured distribution.
+func (d *DelayConfig) Sample() time.Duration {
+	delay := float64(d.Fixed)
+
+	switch d.Distribution {
+	case DistributionUniform:
+		delay += float64(d.Min) + rand.Float64()*float64(d.Max-d.Min)
+	case DistributionNormal:
+		delay += math.Max(0, float64(d.Mean)+rand.NormFloat64()*float64(d.StdDev))
+	case DistributionLogNormal:
+		// derive the parameters of the underlying normal distribution from
+		// the mean and standard deviation of the resulting delays
+		mean, stddev := float64(d.Mean), float64(d.StdDev)
+		sigma2 := math.Log(1 + (stddev*stddev)/(mean*mean))
+		mu := math.Log(mean) - sigma2/2
+		delay += math.Exp(mu + math.Sqrt(sigma2)*rand.NormFloat64())
+	}
+
+	return time.Duration(delay)
+}
+
+// roll reports whether an event with the given percentage should happen.
+func roll(percent int) bool {
+	return percent > 0 && rand.IntN(100) < percent
+}
+
+// sleep pauses for d or until ctx is done, and reports whether the full duration passed.
+func sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return true
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// inject applies the delay, abort and error faults before a response is written,
+// and reports whether the request has been dealt with.
+func (f *FaultConfig) inject(w http.ResponseWriter, req *http.Request) bool {
+	if f.Delay != nil && !sleep(req.Context(), f.Delay.Sample()) {
+		return true
+	}
+
+	if roll(f.AbortPercent) {
+		abort(w)
+		return true
+	}
+
+	if roll(f.ErrorPercent) {
+		status := f.ErrorStatus
+		if status == 0 {
+			status = http.StatusInternalServerError
+		}
+		http.Error(w, http.StatusText(status), status)
+		return true
+	}
+
+	return false
+}
+
+// writeBody writes the body, stalling or truncating it halfway if configured.
+func (f *FaultConfig) writeBody(w http.ResponseWriter, req *http.Request, status int, body []byte) error {
+	truncate := roll(f.TruncatePercent)
+	if f.Stall == 0 && !truncate {
+		w.WriteHeader(status)
+		_, err := w.Write(body)
+		return err
+	}
+
+	// announce the full length so clients notice a truncated body
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(status)
+
+	half := len(body) / 2
+	if _, err := w.Write(body[:half]); err != nil {
+		return err
+	}
+	_ = http.NewResponseController(w).Flush()
+
+	if !sleep(req.Context(), time.Duration(f.Stall)) {
+		return req.Context().Err()
+	}
+
+	if truncate {
+		abort(w)
+		return nil
+	}
+
+	_, err := w.Write(body[half:])
+	return err
+}
+
+// abort closes the client connection without completing the response.
+// Anything already written is flushed to the client first.
+func abort(w http.ResponseWriter) {
+	conn, _, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		// hijacking is not supported (e.g. HTTP/2), let the server abort the stream instead
+		zap.L().Debug("failed to hijack connection, aborting handler", zap.Error(err))
+		panic(http.ErrAbortHandler)
+	}
+	_ = conn.Close()
+}
@@ -0,0 +1,312 @@
+package static
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/antonjah/static/internal/config"
+)
+
+func TestFaults(t *testing.T) {
+	tests := []struct {
+		name string
+		// fault is the YAML fault configuration of the method
+		fault string
+		// http2 serves the request over HTTP/2, where connections cannot be hijacked
+		http2       bool
+		wantStatus  int
+		wantBody    string
+		wantErr     string
+		wantElapsed time.Duration
+	}{
+		{
+			name:       "none",
+			fault:      "{}",
+			wantStatus: 200,
+			wantBody:   "0123456789",
+		},
+		{
+			name:        "delay",
+			fault:       "{delay: {fixed: 100ms}}",
+			wantStatus:  200,
+			wantBody:    "0123456789",
+			wantElapsed: 100 * time.Millisecond,
+		},
+		{
+			name:        "jitter",
+			fault:       "{delay: {fixed: 50ms, distribution: uniform, min: 20ms, max: 40ms}}",
+			wantStatus:  200,
+			wantBody:    "0123456789",
+			wantElapsed: 70 * time.Millisecond,
+		},
+		{
+			name:       "error",
+			fault:      "{error-percent: 100, error-status: 503}",
+			wantStatus: 503,
+			wantBody:   "Service Unavailable\n",
+		},
+		{
+			name:       "truncate",
+			fault:      "{truncate-percent: 100}",
+			wantStatus: 200,
+			wantBody:   "01234",
+			wantErr:    "unexpected EOF",
+		},
+		{
+			name:        "stall",
+			fault:       "{stall: 100ms}",
+			wantStatus:  200,
+			wantBody:    "0123456789",
+			wantElapsed: 100 * time.Millisecond,
+		},
+		{
+			name:    "abort",
+			fault:   "{abort-percent: 100}",
+			wantErr: "EOF",
+		},
+		{
+			name:    "reset",
+			fault:   "{abort-percent: 100}",
+			http2:   true,
+			wantErr: "INTERNAL_ERROR",
+		},
+		{
+			name:       "truncate with a stream reset",
+			fault:      "{truncate-percent: 100}",
+			http2:      true,
+			wantStatus: 200,
+			wantBody:   "01234",
+			wantErr:    "INTERNAL_ERROR",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t, config.Config{}, fmt.Sprintf(`staticapis:
+- path: /fault
+  methods:
+  - method: GET
+    status-code: 200
+    body: "0123456789"
+    fault: %s
+`, tt.fault))
+
+			server := httptest.NewUnstartedServer(s)
+			if tt.http2 {
+				server.EnableHTTP2 = true
+				server.StartTLS()
+			} else {
+				server.Start()
+			}
+			defer server.Close()
+
+			start := time.Now()
+			resp, err := server.Client().Get(server.URL + "/fault")
+			var body []byte
+			if err == nil {
+				body, err = io.ReadAll(resp.Body)
+				resp.Body.Close()
+			}
+			elapsed := time.Since(start)
+
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+			if tt.wantStatus != 0 && resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if string(body) != tt.wantBody {
+				t.Errorf("body = %q, want %q", body, tt.wantBody)
+			}
+			if elapsed < tt.wantElapsed {
+				t.Errorf("elapsed = %s, want at least %s", elapsed, tt.wantElapsed)
+			}
+		})
+	}
+}
+
+func TestFaultStallCanceled(t *testing.T) {
+	s := newTestServer(t, config.Config{}, `staticapis:
+- path: /fault
+  methods:
+  - method: GET
+    status-code: 200
+    body: "0123456789"
+    fault: {stall: 1h}
+`)
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", server.URL+"/fault", nil)
+
+	resp, err := server.Client().Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	// the first half is flushed before the stall
+	body, err := io.ReadAll(resp.Body)
+	if err == nil || string(body) != "01234" {
+		t.Errorf("body = %q, %v, want the first half and a canceled read", body, err)
+	}
+}
+
+// TestFaultsRecorded serves faults with the journal and metrics wrapping the response writer,
+// which must still let them flush and hijack the connection.
+func TestFaultsRecorded(t *testing.T) {
+	s := newTestServer(t, config.Config{JournalSize: 10}, `staticapis:
+- name: truncate
+  path: /truncate
+  methods:
+  - method: GET
+    status-code: 200
+    body: "0123456789"
+    fault: {truncate-percent: 100}
+- name: stall
+  path: /stall
+  methods:
+  - method: GET
+    status-code: 200
+    body: "0123456789"
+    fault: {stall: 1h}
+- name: abort
+  path: /abort
+  methods:
+  - method: GET
+    status-code: 200
+    body: "0123456789"
+    fault: {abort-percent: 100}
+`)
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	resp, err := server.Client().Get(server.URL + "/truncate")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err == nil || string(body) != "01234" {
+		t.Errorf("truncated body = %q, %v, want the first half and an error", body, err)
+	}
+
+	// the first half is flushed before the stall
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", server.URL+"/stall", nil)
+	if resp, err = server.Client().Do(req); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	body, err = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err == nil || string(body) != "01234" {
+		t.Errorf("stalled body = %q, %v, want the first half and a canceled read", body, err)
+	}
+
+	if _, err := server.Client().Get(server.URL + "/abort"); err == nil || !strings.Contains(err.Error(), "EOF") {
+		t.Errorf("error = %v, want EOF", err)
+	}
+
+	// hijacked connections end the handlers normally, so the requests are still counted
+	for _, name := range []string{"truncate", "stall", "abort"} {
+		series := fmt.Sprintf(`static_requests_total{code="200",host="",method="get",name=%q,path="/%s"} 1`, name, name)
+		if !strings.Contains(scrape(t, s), series) {
+			t.Errorf("metrics do not contain %s", series)
+		}
+	}
+}
+
+func TestDelaySample(t *testing.T) {
+	ms := func(n int) Duration { return Duration(time.Duration(n) * time.Millisecond) }
+
+	tests := []struct {
+		name     string
+		delay    DelayConfig
+		min, max time.Duration
+	}{
+		{name: "fixed", delay: DelayConfig{Fixed: ms(10)}, min: 10 * time.Millisecond, max: 10 * time.Millisecond},
+		{name: "uniform", delay: DelayConfig{Fixed: ms(10), Distribution: DistributionUniform, Min: ms(5), Max: ms(15)}, min: 15 * time.Millisecond, max: 25 * time.Millisecond},
+		{name: "normal never negative", delay: DelayConfig{Distribution: DistributionNormal, Mean: ms(1), StdDev: ms(100)}, min: 0, max: time.Hour},
+		{name: "lognormal", delay: DelayConfig{Distribution: DistributionLogNormal, Mean: ms(10), StdDev: ms(5)}, min: 1, max: time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for range 1000 {
+				if d := tt.delay.Sample(); d < tt.min || d > tt.max {
+					t.Fatalf("Sample() = %s, want a delay in [%s, %s]", d, tt.min, tt.max)
+				}
+			}
+		})
+	}
+}
+
+func TestFaultConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		fault   FaultConfig
+		wantErr string
+	}{
+		{
+			name:  "valid",
+			fault: FaultConfig{ErrorPercent: 10, ErrorStatus: 503, Delay: &DelayConfig{Distribution: DistributionLogNormal, Mean: Duration(time.Second)}},
+		},
+		{
+			name:    "percentage out of range",
+			fault:   FaultConfig{TruncatePercent: 101},
+			wantErr: "invalid truncate-percent: 101",
+		},
+		{
+			name:    "invalid error status",
+			fault:   FaultConfig{ErrorStatus: 42},
+			wantErr: "invalid error-status: 42",
+		},
+		{
+			name:    "negative stall",
+			fault:   FaultConfig{Stall: Duration(-time.Second)},
+			wantErr: "invalid stall",
+		},
+		{
+			name:    "uniform max below min",
+			fault:   FaultConfig{Delay: &DelayConfig{Distribution: DistributionUniform, Min: Duration(time.Second)}},
+			wantErr: "max (0s) is less than min (1s)",
+		},
+		{
+			name:    "lognormal without mean",
+			fault:   FaultConfig{Delay: &DelayConfig{Distribution: DistributionLogNormal}},
+			wantErr: "requires a mean",
+		},
+		{
+			name:    "unknown distribution",
+			fault:   FaultConfig{Delay: &DelayConfig{Distribution: "pareto"}},
+			wantErr: `invalid delay distribution "pareto"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.fault.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v, want none", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package static
+
+import (
+	"net/http"
+)
+
+// handleHealthz reports that the server is alive.
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// handleReadyz reports whether the server is ready, i.e. the initial configuration has been
+// loaded from the file or Kubernetes and the StaticAPIs are served.
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	select {
+	case <-s.Synced():
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	default:
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "configuration not loaded"})
+	}
+}
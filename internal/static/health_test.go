@@ -0,0 +1,44 @@
+package static
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/antonjah/static/internal/config"
+)
+
+func TestHealth(t *testing.T) {
+	// a catch-all StaticAPI must not shadow the health endpoints
+	s := newTestServer(t, config.Config{}, `staticapis:
+- path: /{rest...}
+  methods:
+  - method: GET
+    status-code: 404
+`)
+
+	if status, body := serve(s, http.MethodGet, "/_static/healthz", ""); status != http.StatusOK || body != `{"status":"ok"}`+"\n" {
+		t.Errorf("healthz = %d %q, want 200 ok", status, body)
+	}
+	if status, body := serve(s, http.MethodGet, "/_static/readyz", ""); status != http.StatusServiceUnavailable || body != `{"status":"configuration not loaded"}`+"\n" {
+		t.Errorf("readyz before sync = %d %q, want 503", status, body)
+	}
+
+	s.markSynced()
+	if status, body := serve(s, http.MethodGet, "/_static/readyz", ""); status != http.StatusOK || body != `{"status":"ok"}`+"\n" {
+		t.Errorf("readyz after sync = %d %q, want 200 ok", status, body)
+	}
+	// marking the server synced again, e.g. on the next reload, is a no-op
+	s.markSynced()
+}
+
+func TestHealthBeforeLoad(t *testing.T) {
+	// the health endpoints are served before any configuration is loaded
+	s := New(config.Config{ValidationStatus: http.StatusBadRequest, AccessLogFormat: "json"})
+
+	if status, _ := serve(s, http.MethodGet, "/_static/healthz", ""); status != http.StatusOK {
+		t.Errorf("healthz = %d, want %d", status, http.StatusOK)
+	}
+	if status, _ := serve(s, http.MethodGet, "/_static/readyz", ""); status != http.StatusServiceUnavailable {
+		t.Errorf("readyz = %d, want %d", status, http.StatusServiceUnavailable)
+	}
+}
@@ -13,8 +13,12 @@ func (s *Server) handleInfo(w http.ResponseWriter, r *http.Request) {
 	defer s.mu.RUnlock()
 
 	type EndpointInfo struct {
-		Path    string   `json:"path"`
-		Methods []string `json:"methods"`
+		Name    string                  `json:"name,omitempty"`
+		Source  string                  `json:"source"`
+		Host    string                  `json:"host,omitempty"`
+		Path    string                  `json:"path"`
+		Methods []string                `json:"methods"`
+		Faults  map[string]*FaultConfig `json:"faults,omitempty"`
 	}
 
 	type InfoResponse struct {
@@ -24,10 +28,22 @@ func (s *Server) handleInfo(w http.ResponseWriter, r *http.Request) {
 
 	var endpoints []EndpointInfo
 	for _, endpoint := range s.endpoints {
-		endpoints = append(endpoints, EndpointInfo{
+		info := EndpointInfo{
+			Name:    endpoint.Name,
+			Source:  endpoint.source,
+			Host:    endpoint.Host,
 			Path:    endpoint.Path,
 			Methods: endpoint.SupportedMethods,
-		})
+		}
+		for _, method := range endpoint.Methods {
+			if method.Fault != nil {
+				if info.Faults == nil {
+					info.Faults = map[string]*FaultConfig{}
+				}
+				info.Faults[method.Method] = method.Fault
+			}
+		}
+		endpoints = append(endpoints, info)
 	}
 
 	response := InfoResponse{
@@ -0,0 +1,264 @@
+package static
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/exp/slices"
+)
+
+// JournalEntry is a request received by the server
+type JournalEntry struct {
+	Timestamp     time.Time   `json:"timestamp"`
+	Method        string      `json:"method"`
+	URL           string      `json:"url"`
+	Path          string      `json:"path"`
+	Headers       http.Header `json:"headers"`
+	Body          string      `json:"body"`
+	BodyTruncated bool        `json:"bodyTruncated,omitempty"`
+	RemoteAddr    string      `json:"remoteAddr"`
+	StaticAPI     string      `json:"staticAPI,omitempty"`
+	Status        int         `json:"status"`
+	Upstream      string      `json:"upstream,omitempty"`
+
+	ClientCertificate *ClientCertificate `json:"clientCertificate,omitempty"`
+}
+
+// journalEntryKey is the context key of the journal entry of a request
+type journalEntryKey struct{}
+
+// journal is a bounded ring buffer of the most recent requests received by the server
+type journal struct {
+	mu        sync.RWMutex
+	entries   []JournalEntry
+	next      int
+	full      bool
+	bodyLimit int
+}
+
+// newJournal creates a journal keeping the last size requests, capturing at most
+// bodyLimit bytes of each request body. A size of zero disables the journal.
+func newJournal(size, bodyLimit int) *journal {
+	size = max(size, 0)
+	return &journal{
+		entries:   make([]JournalEntry, size),
+		bodyLimit: bodyLimit,
+	}
+}
+
+// add records an entry, replacing the oldest one when the journal is full.
+func (j *journal) add(entry JournalEntry) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.entries[j.next] = entry
+	j.next = (j.next + 1) % len(j.entries)
+	if j.next == 0 {
+		j.full = true
+	}
+}
+
+// list returns the entries matching filter, oldest first.
+func (j *journal) list(filter journalFilter) []JournalEntry {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	ordered := j.entries[:j.next]
+	if j.full {
+		ordered = append(append([]JournalEntry{}, j.entries[j.next:]...), j.entries[:j.next]...)
+	}
+
+	entries := []JournalEntry{}
+	for _, entry := range ordered {
+		if filter.matches(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// clear removes all entries.
+func (j *journal) clear() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.entries = make([]JournalEntry, len(j.entries))
+	j.next = 0
+	j.full = false
+}
+
+// record is a middleware adding every request, except those to the admin endpoints, to the journal.
+func (j *journal) record(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(j.entries) == 0 || strings.HasPrefix(r.URL.Path, "/_static/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		entry := JournalEntry{
+			Timestamp:  time.Now(),
+			Method:     r.Method,
+			URL:        r.URL.String(),
+			Path:       r.URL.Path,
+			Headers:    r.Header.Clone(),
+			RemoteAddr: r.RemoteAddr,
+
+			ClientCertificate: clientCertificate(r),
+		}
+		entry.Body, entry.BodyTruncated = j.captureBody(r)
+
+		// handlers proxying the request mark the entry through the request context
+		r = r.WithContext(context.WithValue(r.Context(), journalEntryKey{}, &entry))
+
+		recorder := newStatusRecorder(w)
+		defer func() {
+			// the mux sets the pattern of the matched StaticAPI on the request
+			entry.StaticAPI = r.Pattern
+			entry.Status = recorder.status
+			j.add(entry)
+		}()
+
+		next.ServeHTTP(recorder, r)
+	})
+}
+
+// captureBody reads up to bodyLimit bytes of the request body and puts them back
+// in front of the remainder, so the handler still sees the full body.
+func (j *journal) captureBody(r *http.Request) (string, bool) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return "", false
+	}
+
+	captured, err := io.ReadAll(io.LimitReader(r.Body, int64(j.bodyLimit)+1))
+	if err != nil {
+		zap.L().Debug("failed to capture request body", zap.Error(err))
+	}
+
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(captured), r.Body), r.Body}
+
+	if len(captured) > j.bodyLimit {
+		return string(captured[:j.bodyLimit]), true
+	}
+	return string(captured), false
+}
+
+// journalFilter selects journal entries by path, matched StaticAPI, method, headers, body
+// and the common name of the client certificate
+type journalFilter struct {
+	path      string
+	staticAPI string
+	method    string
+	headers   map[string]string
+	body      string
+	client    string
+}
+
+// newJournalFilter parses a filter from query parameters, e.g.
+// ?path=/orders&method=POST&header=X-Tenant:acme&body=sku-1&client=billing
+func newJournalFilter(query url.Values) journalFilter {
+	filter := journalFilter{headers: map[string]string{}}
+	filter.path = query.Get("path")
+	filter.staticAPI = query.Get("api")
+	filter.method = query.Get("method")
+	filter.body = query.Get("body")
+	filter.client = query.Get("client")
+	for _, header := range query["header"] {
+		name, value, _ := strings.Cut(header, ":")
+		filter.headers[http.CanonicalHeaderKey(strings.TrimSpace(name))] = strings.TrimSpace(value)
+	}
+	return filter
+}
+
+// matches reports whether entry satisfies all criteria of the filter.
+// Headers without a value only have to be present.
+func (f journalFilter) matches(entry JournalEntry) bool {
+	if f.path != "" && entry.Path != f.path {
+		return false
+	}
+	if f.staticAPI != "" && entry.StaticAPI != f.staticAPI {
+		return false
+	}
+	if f.method != "" && !strings.EqualFold(entry.Method, f.method) {
+		return false
+	}
+	if f.body != "" && !strings.Contains(entry.Body, f.body) {
+		return false
+	}
+	if f.client != "" && (entry.ClientCertificate == nil || entry.ClientCertificate.CommonName != f.client) {
+		return false
+	}
+	for name, value := range f.headers {
+		values, ok := entry.Headers[name]
+		if !ok || (value != "" && !slices.Contains(values, value)) {
+			return false
+		}
+	}
+	return true
+}
+
+// handleRequests lists the journal entries matching the query filter on GET, and clears the journal on DELETE.
+func (s *Server) handleRequests(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.journal.list(newJournalFilter(r.URL.Query())))
+	case http.MethodDelete:
+		s.journal.clear()
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// handleRequestsCount returns the number of journal entries matching the query filter.
+// If an expected count is given with ?expect=N, the response status tells whether it
+// matched: 200 OK if it did, 417 Expectation Failed otherwise.
+func (s *Server) handleRequestsCount(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	type CountResponse struct {
+		Count    int  `json:"count"`
+		Expected *int `json:"expected,omitempty"`
+	}
+
+	query := r.URL.Query()
+	response := CountResponse{Count: len(s.journal.list(newJournalFilter(query)))}
+
+	status := http.StatusOK
+	if query.Has("expect") {
+		expected, err := strconv.Atoi(query.Get("expect"))
+		if err != nil {
+			http.Error(w, "invalid expect parameter", http.StatusBadRequest)
+			return
+		}
+		response.Expected = &expected
+		if expected != response.Count {
+			status = http.StatusExpectationFailed
+		}
+	}
+
+	writeJSON(w, status, response)
+}
+
+// writeJSON writes v as a JSON response with the given status code.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		zap.L().Error("failed to encode response", zap.Error(err))
+	}
+}
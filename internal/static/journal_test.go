@@ -0,0 +1,214 @@
+package static
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/antonjah/static/internal/config"
+)
+
+func TestJournalWraparound(t *testing.T) {
+	j := newJournal(3, 0)
+	for _, path := range []string{"/1", "/2", "/3", "/4", "/5"} {
+		j.add(JournalEntry{Path: path})
+	}
+
+	var paths []string
+	for _, entry := range j.list(journalFilter{}) {
+		paths = append(paths, entry.Path)
+	}
+	if want := []string{"/3", "/4", "/5"}; !slices.Equal(paths, want) {
+		t.Errorf("paths = %v, want %v", paths, want)
+	}
+
+	j.clear()
+	if entries := j.list(journalFilter{}); len(entries) != 0 {
+		t.Errorf("entries after clear = %v, want none", entries)
+	}
+}
+
+func TestJournalFilter(t *testing.T) {
+	j := newJournal(10, 0)
+	j.add(JournalEntry{Method: "GET", Path: "/orders", StaticAPI: "/orders"})
+	j.add(JournalEntry{Method: "POST", Path: "/orders", StaticAPI: "/orders", Body: `{"sku": "sku-1"}`,
+		Headers: http.Header{"X-Tenant": {"acme"}}})
+	j.add(JournalEntry{Method: "POST", Path: "/orders/1", StaticAPI: "/orders/{id}", Body: `{"sku": "sku-2"}`,
+		Headers: http.Header{"X-Tenant": {"other"}}})
+
+	tests := []struct {
+		name  string
+		query string
+		want  int
+	}{
+		{name: "all", query: "", want: 3},
+		{name: "path", query: "path=/orders", want: 2},
+		{name: "StaticAPI", query: "api=/orders/{id}", want: 1},
+		{name: "method ignores case", query: "method=post", want: 2},
+		{name: "header value", query: "header=X-Tenant:acme", want: 1},
+		{name: "header present", query: "header=x-tenant", want: 2},
+		{name: "body", query: "body=sku-2", want: 1},
+		{name: "all criteria", query: "method=POST&path=/orders&header=X-Tenant:acme&body=sku-1", want: 1},
+		{name: "no match", query: "method=DELETE", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := j.list(newJournalFilter(query)); len(got) != tt.want {
+				t.Errorf("entries = %d, want %d", len(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestJournalRecord(t *testing.T) {
+	s := newTestServer(t, config.Config{JournalSize: 10, JournalBodyLimit: 4}, `staticapis:
+- path: /orders/{id}
+  methods:
+  - method: POST
+    status-code: 201
+    responses:
+    - match:
+        body: {equals: "0123456789"}
+      status-code: 202
+`)
+
+	// the handler still sees the full body
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest("POST", "/orders/1", strings.NewReader("0123456789")))
+	if w.Code != 202 {
+		t.Errorf("status = %d, want 202", w.Code)
+	}
+	s.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/orders/2", strings.NewReader("0123")))
+	s.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/unknown", nil))
+	s.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/_static/info", nil))
+
+	entries := s.journal.list(journalFilter{})
+	if len(entries) != 3 {
+		t.Fatalf("entries = %d, want 3 without the admin request", len(entries))
+	}
+
+	tests := []struct {
+		entry         JournalEntry
+		wantBody      string
+		wantTruncated bool
+		wantStaticAPI string
+		wantStatus    int
+	}{
+		{entry: entries[0], wantBody: "0123", wantTruncated: true, wantStaticAPI: "/orders/{id}", wantStatus: 202},
+		{entry: entries[1], wantBody: "0123", wantTruncated: false, wantStaticAPI: "/orders/{id}", wantStatus: 201},
+		{entry: entries[2], wantBody: "", wantTruncated: false, wantStaticAPI: "", wantStatus: 404},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.entry.Path, func(t *testing.T) {
+			if tt.entry.Body != tt.wantBody || tt.entry.BodyTruncated != tt.wantTruncated {
+				t.Errorf("body = %q (truncated: %v), want %q (truncated: %v)",
+					tt.entry.Body, tt.entry.BodyTruncated, tt.wantBody, tt.wantTruncated)
+			}
+			if tt.entry.StaticAPI != tt.wantStaticAPI {
+				t.Errorf("StaticAPI = %q, want %q", tt.entry.StaticAPI, tt.wantStaticAPI)
+			}
+			if tt.entry.Status != tt.wantStatus {
+				t.Errorf("status = %d, want %d", tt.entry.Status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestJournalDisabled(t *testing.T) {
+	s := newTestServer(t, config.Config{}, `staticapis:
+- path: /orders
+  methods:
+  - method: GET
+    status-code: 200
+`)
+	s.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/orders", nil))
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest("GET", "/_static/requests/count", nil))
+	if !strings.Contains(w.Body.String(), `"count":0`) {
+		t.Errorf("body = %s, want a count of 0", w.Body)
+	}
+}
+
+func TestHandleRequests(t *testing.T) {
+	s := newTestServer(t, config.Config{JournalSize: 10, JournalBodyLimit: 1024}, `staticapis:
+- path: /orders
+  methods:
+  - method: GET
+    status-code: 200
+  - method: POST
+    status-code: 201
+`)
+	for _, method := range []string{"GET", "POST", "POST"} {
+		s.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, "/orders", nil))
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "count", method: "GET", target: "/_static/requests/count?method=POST", wantStatus: 200, wantBody: `{"count":2}`},
+		{name: "expected count", method: "GET", target: "/_static/requests/count?method=POST&expect=2", wantStatus: 200, wantBody: `{"count":2,"expected":2}`},
+		{name: "unexpected count", method: "GET", target: "/_static/requests/count?method=POST&expect=1", wantStatus: 417, wantBody: `{"count":2,"expected":1}`},
+		{name: "invalid expected count", method: "GET", target: "/_static/requests/count?expect=two", wantStatus: 400, wantBody: "invalid expect parameter"},
+		{name: "count is read-only", method: "POST", target: "/_static/requests/count", wantStatus: 405},
+		{name: "list", method: "GET", target: "/_static/requests?method=GET", wantStatus: 200, wantBody: `"method":"GET"`},
+		{name: "list is not writable", method: "PUT", target: "/_static/requests", wantStatus: 405},
+		{name: "clear", method: "DELETE", target: "/_static/requests", wantStatus: 204},
+		{name: "count after clear", method: "GET", target: "/_static/requests/count?expect=0", wantStatus: 200, wantBody: `{"count":0,"expected":0}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			s.ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, nil))
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("body = %s, want %s", w.Body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestHandleRequestsList(t *testing.T) {
+	s := newTestServer(t, config.Config{JournalSize: 10, JournalBodyLimit: 1024}, `staticapis:
+- path: /orders
+  methods:
+  - method: POST
+    status-code: 201
+`)
+	req := httptest.NewRequest("POST", "/orders?id=1", strings.NewReader("sku-1"))
+	req.Header.Set("X-Tenant", "acme")
+	s.ServeHTTP(httptest.NewRecorder(), req)
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest("GET", "/_static/requests", nil))
+
+	var entries []JournalEntry
+	if err := json.NewDecoder(w.Body).Decode(&entries); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("entries = %d, want 1", len(entries))
+	}
+	entry := entries[0]
+	if entry.Method != "POST" || entry.URL != "/orders?id=1" || entry.Path != "/orders" || entry.Body != "sku-1" ||
+		entry.Headers.Get("X-Tenant") != "acme" || entry.StaticAPI != "/orders" || entry.Status != 201 || entry.Timestamp.IsZero() {
+		t.Errorf("entry = %+v", entry)
+	}
+}
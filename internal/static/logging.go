@@ -1,18 +1,279 @@
 package static
 
 import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
 	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/exp/slices"
 )
 
-func requestLogger(next http.Handler) http.Handler {
+// AccessLogFormats are the formats access logs can be written in
+var AccessLogFormats = []string{"json", "combined", "logfmt"}
+
+// accessEntry is a request logged after its response was written
+type accessEntry struct {
+	Time        time.Time `json:"time"`
+	RemoteAddr  string    `json:"remoteAddr"`
+	Method      string    `json:"method"`
+	URL         string    `json:"url"`
+	Protocol    string    `json:"protocol"`
+	Status      int       `json:"status"`
+	Bytes       int       `json:"bytes"`
+	DurationMs  float64   `json:"durationMs"`
+	StaticAPI   string    `json:"staticAPI,omitempty"`
+	Upstream    string    `json:"upstream,omitempty"`
+	UserAgent   string    `json:"userAgent,omitempty"`
+	Referer     string    `json:"referer,omitempty"`
+	TLSClientCN string    `json:"tlsClientCN,omitempty"`
+
+	// the verified TLS client certificate beyond its common name
+	TLSClientOU          string `json:"tlsClientOU,omitempty"`
+	TLSClientSANs        string `json:"tlsClientSANs,omitempty"`
+	TLSClientFingerprint string `json:"tlsClientFingerprint,omitempty"`
+}
+
+// accessEntryKey is the context key of the access log entry of a request
+type accessEntryKey struct{}
+
+// accessLog logs every request once its response is written. Without an output the
+// entries are logged at debug level, otherwise they are written to the output in the
+// configured format regardless of LOG_LEVEL.
+type accessLog struct {
+	mu     sync.Mutex
+	out    io.Writer
+	format string
+}
+
+// newAccessLog creates an access log writing to output, which is stdout, stderr or a file
+// path, in format. An empty output logs the entries at debug level.
+func newAccessLog(output, format string) (*accessLog, error) {
+	if !slices.Contains(AccessLogFormats, format) {
+		return nil, fmt.Errorf("unsupported access log format %q, expected one of %s", format, strings.Join(AccessLogFormats, ", "))
+	}
+
+	a := &accessLog{format: format}
+	switch output {
+	case "":
+	case "stdout":
+		a.out = os.Stdout
+	case "stderr":
+		a.out = os.Stderr
+	default:
+		file, err := os.OpenFile(output, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open access log: %w", err)
+		}
+		a.out = file
+	}
+	return a, nil
+}
+
+// record is a middleware logging every request after its response.
+func (a *accessLog) record(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if a.out == nil && !zap.L().Core().Enabled(zapcore.DebugLevel) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		entry := accessEntry{
+			Time:       time.Now(),
+			RemoteAddr: r.RemoteAddr,
+			Method:     r.Method,
+			URL:        r.URL.RequestURI(),
+			Protocol:   r.Proto,
+			UserAgent:  r.UserAgent(),
+			Referer:    r.Referer(),
+		}
+		if identity := clientCertificate(r); identity != nil {
+			entry.TLSClientCN = identity.CommonName
+			entry.TLSClientOU = strings.Join(identity.OrganizationalUnits, ",")
+			entry.TLSClientSANs = strings.Join(identity.SANs, ",")
+			entry.TLSClientFingerprint = identity.Fingerprint
+		}
+
+		// the StaticAPI and upstream serving the request are set through the request context
+		r = r.WithContext(context.WithValue(r.Context(), accessEntryKey{}, &entry))
+
+		recorder := newStatusRecorder(w)
+		defer func() {
+			entry.Status = recorder.status
+			entry.Bytes = recorder.bytes
+			entry.DurationMs = float64(time.Since(entry.Time).Microseconds()) / 1000
+			a.write(&entry)
+		}()
+
+		next.ServeHTTP(recorder, r)
+	})
+}
+
+// write logs entry in the format of the access log.
+func (a *accessLog) write(entry *accessEntry) {
+	if a.out == nil {
 		zap.L().Debug("request",
-			zap.String("address", r.RemoteAddr),
-			zap.String("method", r.Method),
-			zap.String("path", r.URL.Path))
+			zap.String("address", entry.RemoteAddr),
+			zap.String("method", entry.Method),
+			zap.String("url", entry.URL),
+			zap.Int("status", entry.Status),
+			zap.Int("bytes", entry.Bytes),
+			zap.Float64("durationMs", entry.DurationMs),
+			zap.String("staticAPI", entry.StaticAPI),
+			zap.String("upstream", entry.Upstream),
+			zap.String("userAgent", entry.UserAgent),
+			zap.String("tlsClientCN", entry.TLSClientCN),
+			zap.String("tlsClientFingerprint", entry.TLSClientFingerprint))
+		return
+	}
+
+	var line []byte
+	switch a.format {
+	case "combined":
+		line = entry.combined()
+	case "logfmt":
+		line = entry.logfmt()
+	default:
+		line, _ = json.Marshal(entry)
+		line = append(line, '\n')
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, err := a.out.Write(line); err != nil {
+		zap.L().Error("failed to write access log", zap.Error(err))
+	}
+}
+
+// combined formats the entry in the Apache combined log format, with the TLS client CN
+// as the user.
+func (e *accessEntry) combined() []byte {
+	host, _, err := net.SplitHostPort(e.RemoteAddr)
+	if err != nil {
+		host = e.RemoteAddr
+	}
+	bytes := "-"
+	if e.Bytes > 0 {
+		bytes = strconv.Itoa(e.Bytes)
+	}
+	return fmt.Appendf(nil, "%s - %s [%s] \"%s %s %s\" %d %s %s %s\n",
+		host,
+		orDash(e.TLSClientCN),
+		e.Time.Format("02/Jan/2006:15:04:05 -0700"),
+		e.Method, e.URL, e.Protocol,
+		e.Status,
+		bytes,
+		strconv.Quote(orDash(e.Referer)),
+		strconv.Quote(orDash(e.UserAgent)))
+}
 
+// logfmt formats the entry as logfmt key=value pairs, leaving out empty optional values.
+func (e *accessEntry) logfmt() []byte {
+	var b strings.Builder
+	pair := func(key, value string) {
+		if b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(key)
+		b.WriteByte('=')
+		if value == "" || strings.ContainsAny(value, " =\"\\") || strings.ContainsFunc(value, isControl) {
+			value = strconv.Quote(value)
+		}
+		b.WriteString(value)
+	}
+	optional := func(key, value string) {
+		if value != "" {
+			pair(key, value)
+		}
+	}
+
+	pair("time", e.Time.Format(time.RFC3339Nano))
+	pair("remoteAddr", e.RemoteAddr)
+	pair("method", e.Method)
+	pair("url", e.URL)
+	pair("protocol", e.Protocol)
+	pair("status", strconv.Itoa(e.Status))
+	pair("bytes", strconv.Itoa(e.Bytes))
+	pair("durationMs", strconv.FormatFloat(e.DurationMs, 'f', -1, 64))
+	optional("staticAPI", e.StaticAPI)
+	optional("upstream", e.Upstream)
+	optional("userAgent", e.UserAgent)
+	optional("referer", e.Referer)
+	optional("tlsClientCN", e.TLSClientCN)
+	optional("tlsClientOU", e.TLSClientOU)
+	optional("tlsClientSANs", e.TLSClientSANs)
+	optional("tlsClientFingerprint", e.TLSClientFingerprint)
+	b.WriteByte('\n')
+	return []byte(b.String())
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+func isControl(r rune) bool {
+	return r < ' ' || r == 0x7f
+}
+
+// logStaticAPI marks the requests served by staticAPI in the access log with its pattern,
+// which unlike its name is also set in file mode, and is recorded in the journal as well.
+func logStaticAPI(staticAPI *StaticAPI, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if entry, ok := r.Context().Value(accessEntryKey{}).(*accessEntry); ok {
+			entry.StaticAPI = staticAPI.Pattern()
+		}
 		next.ServeHTTP(w, r)
 	})
 }
+
+// statusRecorder records the status code and number of body bytes written to a response.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+// newStatusRecorder wraps w, assuming 200 OK until a status is written.
+func newStatusRecorder(w http.ResponseWriter) *statusRecorder {
+	return &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += n
+	return n, err
+}
+
+// Flush flushes the underlying ResponseWriter. Middleware wrapping the recorder, e.g. the
+// metrics, only forward the interfaces it implements itself.
+func (r *statusRecorder) Flush() {
+	_ = http.NewResponseController(r.ResponseWriter).Flush()
+}
+
+// Hijack takes over the connection of the underlying ResponseWriter, e.g. to abort a response.
+func (r *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(r.ResponseWriter).Hijack()
+}
+
+// Unwrap returns the underlying ResponseWriter so http.ResponseController can reach it.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
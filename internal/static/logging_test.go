@@ -0,0 +1,48 @@
+package static
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLogStaticAPI(t *testing.T) {
+	tests := []struct {
+		name      string
+		staticAPI StaticAPI
+		want      string
+	}{
+		{name: "file mode", staticAPI: StaticAPI{Path: "/users/{id}"}, want: "/users/{id}"},
+		{name: "named", staticAPI: StaticAPI{Name: "users", Path: "/users/{id}"}, want: "/users/{id}"},
+		{name: "host", staticAPI: StaticAPI{Host: "api.example.com", Path: "/users/{id}"}, want: "api.example.com/users/{id}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := filepath.Join(t.TempDir(), "access.log")
+			accessLog, err := newAccessLog(output, "json")
+			if err != nil {
+				t.Fatal(err)
+			}
+			handler := accessLog.record(logStaticAPI(&tt.staticAPI, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNoContent)
+			})))
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/1", nil))
+
+			data, err := os.ReadFile(output)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var entry accessEntry
+			if err := json.Unmarshal(data, &entry); err != nil {
+				t.Fatalf("invalid entry %q: %v", data, err)
+			}
+			if entry.StaticAPI != tt.want || entry.Status != http.StatusNoContent {
+				t.Errorf("staticAPI = %q, status = %d, want %q, %d", entry.StaticAPI, entry.Status, tt.want, http.StatusNoContent)
+			}
+		})
+	}
+}
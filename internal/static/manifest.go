@@ -0,0 +1,246 @@
+package static
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/antonjah/static/internal/openapi/spec"
+	staticv1alpha1 "github.com/antonjah/static/pkg/apis/static/v1alpha1"
+	"gopkg.in/yaml.v3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	sigsyaml "sigs.k8s.io/yaml"
+)
+
+// Output formats for generated StaticAPIs
+const (
+	FormatYAML = "yaml" // the staticapis.yaml file format
+	FormatCRD  = "crd"  // StaticAPI custom resource manifests
+)
+
+// staticAPIManifest is a StaticAPI CRD manifest without any server populated metadata
+type staticAPIManifest struct {
+	APIVersion string                       `json:"apiVersion"`
+	Kind       string                       `json:"kind"`
+	Metadata   manifestMetadata             `json:"metadata"`
+	Spec       staticv1alpha1.StaticAPISpec `json:"spec"`
+}
+
+type manifestMetadata struct {
+	Name string `json:"name"`
+}
+
+// WriteStaticAPIs writes staticAPIs to w in the given format: a staticapis.yaml
+// file, or a multi-document YAML stream of StaticAPI manifests.
+func WriteStaticAPIs(w io.Writer, staticAPIs []StaticAPI, format string) error {
+	switch format {
+	case FormatYAML:
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(StaticAPIs{StaticAPIs: staticAPIs}); err != nil {
+			return err
+		}
+		return encoder.Close()
+
+	case FormatCRD:
+		for i, staticAPI := range staticAPIs {
+			name := staticAPI.Name
+			if name == "" {
+				name = resourceName(staticAPI.Path)
+			}
+
+			data, err := sigsyaml.Marshal(staticAPIManifest{
+				APIVersion: staticv1alpha1.GroupVersion.String(),
+				Kind:       "StaticAPI",
+				Metadata:   manifestMetadata{Name: name},
+				Spec:       convertFromStaticAPI(staticAPI),
+			})
+			if err != nil {
+				return err
+			}
+
+			if i > 0 {
+				if _, err := io.WriteString(w, "---\n"); err != nil {
+					return err
+				}
+			}
+			if _, err := w.Write(data); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("invalid format %q, must be %s or %s", format, FormatYAML, FormatCRD)
+	}
+}
+
+// nonAlphanumeric matches runs of characters not allowed in Kubernetes resource names
+var nonAlphanumeric = regexp.MustCompile(`[^a-z0-9]+`)
+
+// resourceName derives a Kubernetes resource name from a path, e.g. "/api/users/{id}" becomes "api-users-id".
+func resourceName(path string) string {
+	name := strings.Trim(nonAlphanumeric.ReplaceAllString(strings.ToLower(path), "-"), "-")
+	if len(name) > 63 {
+		name = strings.TrimRight(name[:63], "-")
+	}
+	if name == "" {
+		return "root"
+	}
+	return name
+}
+
+// convertFromStaticAPI converts an internal StaticAPI to a Kubernetes StaticAPI CRD spec.
+func convertFromStaticAPI(staticAPI StaticAPI) staticv1alpha1.StaticAPISpec {
+	methods := make([]staticv1alpha1.Method, len(staticAPI.Methods))
+	for i, m := range staticAPI.Methods {
+		methods[i] = staticv1alpha1.Method{
+			Method:       m.Method,
+			StatusCode:   m.StatusCode,
+			Body:         m.Body,
+			Headers:      m.Headers,
+			BodyFile:     m.BodyFile,
+			BodyEncoding: m.BodyEncoding,
+			Responses:    convertFromResponses(m.Responses),
+			Sequence:     convertFromResponses(m.Sequence),
+			SequenceMode: m.SequenceMode,
+			ExpandParams: m.ExpandParams,
+			Template:     m.Template,
+			Fault:        convertFromFault(m.Fault),
+			Validation:   convertFromValidation(m.Validation),
+		}
+	}
+	return staticv1alpha1.StaticAPISpec{
+		Host:    staticAPI.Host,
+		Path:    staticAPI.Path,
+		Methods: methods,
+	}
+}
+
+// convertFromResponses converts response variants to their StaticAPI CRD form.
+func convertFromResponses(responses []ResponseConfig) []staticv1alpha1.Response {
+	if len(responses) == 0 {
+		return nil
+	}
+
+	converted := make([]staticv1alpha1.Response, len(responses))
+	for i, r := range responses {
+		converted[i] = staticv1alpha1.Response{
+			Match: staticv1alpha1.ResponseMatch{
+				Query:   convertFromValueMatches(r.Match.Query),
+				Headers: convertFromValueMatches(r.Match.Headers),
+				Cookies: convertFromValueMatches(r.Match.Cookies),
+			},
+			StatusCode:   r.StatusCode,
+			Body:         r.Body,
+			Headers:      r.Headers,
+			BodyFile:     r.BodyFile,
+			BodyEncoding: r.BodyEncoding,
+			Weight:       r.Weight,
+		}
+
+		if b := r.Match.Body; b != nil {
+			body := &staticv1alpha1.BodyMatch{Equals: b.Equals, Regex: b.Regex}
+			for _, j := range b.JSONPath {
+				body.JSONPath = append(body.JSONPath, staticv1alpha1.JSONPathMatch{Path: j.Path, Equals: j.Equals})
+			}
+			converted[i].Match.Body = body
+		}
+
+		if c := r.Match.ClientCertificate; c != nil {
+			converted[i].Match.ClientCertificate = &staticv1alpha1.ClientCertificateMatch{
+				CommonName:         convertFromValueMatch(c.CommonName),
+				OrganizationalUnit: convertFromValueMatch(c.OrganizationalUnit),
+				SAN:                convertFromValueMatch(c.SAN),
+				Fingerprint:        c.Fingerprint,
+			}
+		}
+	}
+	return converted
+}
+
+// convertFromValueMatch converts a single match rule to its StaticAPI CRD form.
+func convertFromValueMatch(match *ValueMatch) *staticv1alpha1.ValueMatch {
+	if match == nil {
+		return nil
+	}
+	return &staticv1alpha1.ValueMatch{Equals: match.Equals, Regex: match.Regex}
+}
+
+// convertFromValueMatches converts query, header or cookie match rules to their StaticAPI CRD form.
+func convertFromValueMatches(matches map[string]ValueMatch) map[string]staticv1alpha1.ValueMatch {
+	if len(matches) == 0 {
+		return nil
+	}
+
+	converted := make(map[string]staticv1alpha1.ValueMatch, len(matches))
+	for name, m := range matches {
+		converted[name] = staticv1alpha1.ValueMatch{Equals: m.Equals, Regex: m.Regex}
+	}
+	return converted
+}
+
+// convertFromFault converts fault injection settings to their StaticAPI CRD form.
+func convertFromFault(f *FaultConfig) *staticv1alpha1.Fault {
+	if f == nil {
+		return nil
+	}
+
+	fault := &staticv1alpha1.Fault{
+		ErrorPercent:    f.ErrorPercent,
+		ErrorStatus:     f.ErrorStatus,
+		AbortPercent:    f.AbortPercent,
+		TruncatePercent: f.TruncatePercent,
+		Stall:           convertFromDuration(f.Stall),
+	}
+
+	if d := f.Delay; d != nil {
+		fault.Delay = &staticv1alpha1.Delay{
+			Fixed:        convertFromDuration(d.Fixed),
+			Distribution: d.Distribution,
+			Min:          convertFromDuration(d.Min),
+			Max:          convertFromDuration(d.Max),
+			Mean:         convertFromDuration(d.Mean),
+			StdDev:       convertFromDuration(d.StdDev),
+		}
+	}
+	return fault
+}
+
+// convertFromValidation converts request validation schemas to their StaticAPI CRD form.
+func convertFromValidation(v *ValidationConfig) *staticv1alpha1.Validation {
+	if v == nil {
+		return nil
+	}
+	return &staticv1alpha1.Validation{
+		Params:     convertFromSchema(v.Params),
+		Query:      convertFromSchema(v.Query),
+		Headers:    convertFromSchema(v.Headers),
+		Body:       convertFromSchema(v.Body),
+		StatusCode: v.StatusCode,
+	}
+}
+
+// convertFromSchema embeds a JSON schema in a StaticAPI CRD.
+func convertFromSchema(schema *spec.Schema) *runtime.RawExtension {
+	if schema == nil {
+		return nil
+	}
+	raw, err := json.Marshal(schema)
+	if err != nil {
+		return nil
+	}
+	return &runtime.RawExtension{Raw: raw}
+}
+
+// convertFromDuration converts a duration to an optional Kubernetes duration.
+func convertFromDuration(d Duration) *metav1.Duration {
+	if d == 0 {
+		return nil
+	}
+	return &metav1.Duration{Duration: time.Duration(d)}
+}
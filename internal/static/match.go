@@ -0,0 +1,294 @@
+package static
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// regexpCache holds compiled match expressions keyed by their source
+var regexpCache = newCache[*regexp.Regexp](1024)
+
+// compileRegexp returns the compiled form of expr, compiling it only once while it is cached.
+func compileRegexp(expr string) (*regexp.Regexp, error) {
+	if re, ok := regexpCache.Load(expr); ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	regexpCache.Store(expr, re)
+	return re, nil
+}
+
+// Match returns the first response variant matching req.
+func (m *MethodConfig) Match(req *http.Request) (ResponseConfig, bool) {
+	if len(m.Responses) == 0 {
+		return ResponseConfig{}, false
+	}
+
+	var body []byte
+	if m.matchesBody() {
+		body = readBody(req)
+	}
+
+	for _, response := range m.Responses {
+		if response.Match.Matches(req, body) {
+			return response, true
+		}
+	}
+	return ResponseConfig{}, false
+}
+
+// DefaultResponse returns the response used when no variant matches.
+func (m *MethodConfig) DefaultResponse() ResponseConfig {
+	return ResponseConfig{
+		StatusCode:   m.StatusCode,
+		Body:         m.Body,
+		Headers:      m.Headers,
+		BodyFile:     m.BodyFile,
+		BodyEncoding: m.BodyEncoding,
+	}
+}
+
+// matchesBody reports whether any of the response variants inspects the request body.
+func (m *MethodConfig) matchesBody() bool {
+	for _, response := range m.Responses {
+		if response.Match.Body != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// readBody reads the request body and replaces it so it can be read again.
+// It returns nil if the body cannot be read, e.g. when it exceeds the limit
+// Server.ServeHTTP puts on request bodies.
+func readBody(req *http.Request) []byte {
+	if req.Body == nil {
+		return nil
+	}
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return body
+}
+
+// Matches reports whether the request satisfies all rules.
+func (c *MatchConfig) Matches(req *http.Request, body []byte) bool {
+	query := req.URL.Query()
+	for name, match := range c.Query {
+		if !query.Has(name) || !match.Matches(query.Get(name)) {
+			return false
+		}
+	}
+
+	for name, match := range c.Headers {
+		values := req.Header.Values(name)
+		if len(values) == 0 || !match.Matches(strings.Join(values, ",")) {
+			return false
+		}
+	}
+
+	for name, match := range c.Cookies {
+		cookie, err := req.Cookie(name)
+		if err != nil || !match.Matches(cookie.Value) {
+			return false
+		}
+	}
+
+	if c.Body != nil && !c.Body.Matches(body) {
+		return false
+	}
+
+	if c.ClientCertificate != nil && !c.ClientCertificate.Matches(req) {
+		return false
+	}
+
+	return true
+}
+
+// Validate checks that all regular expressions and JSON paths are valid.
+func (c *MatchConfig) Validate() error {
+	for kind, matches := range map[string]map[string]ValueMatch{
+		"query":  c.Query,
+		"header": c.Headers,
+		"cookie": c.Cookies,
+	} {
+		for name, match := range matches {
+			if err := match.Validate(); err != nil {
+				return fmt.Errorf("invalid %s match %s: %w", kind, name, err)
+			}
+		}
+	}
+
+	if c.Body != nil {
+		if err := c.Body.Validate(); err != nil {
+			return fmt.Errorf("invalid body match: %w", err)
+		}
+	}
+
+	if c.ClientCertificate != nil {
+		if err := c.ClientCertificate.Validate(); err != nil {
+			return fmt.Errorf("invalid client certificate match: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Matches reports whether value satisfies the match.
+func (v *ValueMatch) Matches(value string) bool {
+	if v.Equals != "" && value != v.Equals {
+		return false
+	}
+	if v.Regex != "" {
+		re, err := compileRegexp(v.Regex)
+		if err != nil || !re.MatchString(value) {
+			return false
+		}
+	}
+	return true
+}
+
+// Validate checks that the regular expression, if any, compiles.
+func (v *ValueMatch) Validate() error {
+	if v.Regex == "" {
+		return nil
+	}
+	_, err := compileRegexp(v.Regex)
+	return err
+}
+
+// Matches reports whether body satisfies the match.
+func (b *BodyMatch) Matches(body []byte) bool {
+	if b.Equals != "" && string(body) != b.Equals {
+		return false
+	}
+
+	if b.Regex != "" {
+		re, err := compileRegexp(b.Regex)
+		if err != nil || !re.Match(body) {
+			return false
+		}
+	}
+
+	if len(b.JSONPath) > 0 {
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.UseNumber()
+
+		var document any
+		if err := decoder.Decode(&document); err != nil {
+			return false
+		}
+
+		for _, match := range b.JSONPath {
+			value, ok := lookupJSONPath(document, match.Path)
+			if !ok || jsonString(value) != match.Equals {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// Validate checks that the regular expression and JSON paths, if any, are valid.
+func (b *BodyMatch) Validate() error {
+	if b.Regex != "" {
+		if _, err := compileRegexp(b.Regex); err != nil {
+			return err
+		}
+	}
+	for _, match := range b.JSONPath {
+		if _, err := parseJSONPath(match.Path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseJSONPath splits a JSON path such as "$.items[0].id" into its keys and indices.
+// The leading "$" is optional.
+func parseJSONPath(path string) ([]string, error) {
+	trimmed := strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	if trimmed == "" {
+		return nil, nil
+	}
+
+	var keys []string
+	for _, part := range strings.Split(trimmed, ".") {
+		name, indices, hasIndex := strings.Cut(part, "[")
+		if name == "" && indices == "" {
+			return nil, fmt.Errorf("invalid JSON path %q: empty key", path)
+		}
+		if name != "" {
+			keys = append(keys, name)
+		}
+		if !hasIndex {
+			continue
+		}
+
+		// indices holds the remainder after the first '[', e.g. "0][1]"
+		for _, index := range strings.Split(strings.TrimSuffix(indices, "]"), "][") {
+			if _, err := strconv.Atoi(index); err != nil {
+				return nil, fmt.Errorf("invalid JSON path %q: invalid index %q", path, index)
+			}
+			keys = append(keys, "["+index+"]")
+		}
+		if !strings.HasSuffix(indices, "]") {
+			return nil, fmt.Errorf("invalid JSON path %q: missing ']'", path)
+		}
+	}
+	return keys, nil
+}
+
+// lookupJSONPath returns the value found at path in a decoded JSON document.
+func lookupJSONPath(document any, path string) (any, bool) {
+	keys, err := parseJSONPath(path)
+	if err != nil {
+		return nil, false
+	}
+
+	current := document
+	for _, key := range keys {
+		if strings.HasPrefix(key, "[") {
+			index, _ := strconv.Atoi(key[1 : len(key)-1])
+			array, ok := current.([]any)
+			if !ok || index < 0 || index >= len(array) {
+				return nil, false
+			}
+			current = array[index]
+			continue
+		}
+
+		object, ok := current.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if current, ok = object[key]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// jsonString returns strings as-is and any other JSON value in its encoded form.
+func jsonString(value any) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
@@ -0,0 +1,285 @@
+package static
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/antonjah/static/internal/config"
+)
+
+func TestMatchConfigMatches(t *testing.T) {
+	tests := []struct {
+		name  string
+		match MatchConfig
+		path  string
+		setup func(req *http.Request)
+		body  string
+		want  bool
+	}{
+		{
+			name: "empty matches every request",
+			path: "/",
+			want: true,
+		},
+		{
+			name:  "query equals",
+			match: MatchConfig{Query: map[string]ValueMatch{"type": {Equals: "admin"}}},
+			path:  "/?type=admin",
+			want:  true,
+		},
+		{
+			name:  "query differs",
+			match: MatchConfig{Query: map[string]ValueMatch{"type": {Equals: "admin"}}},
+			path:  "/?type=user",
+		},
+		{
+			name:  "query present",
+			match: MatchConfig{Query: map[string]ValueMatch{"debug": {}}},
+			path:  "/?debug",
+			want:  true,
+		},
+		{
+			name:  "query missing",
+			match: MatchConfig{Query: map[string]ValueMatch{"debug": {}}},
+			path:  "/",
+		},
+		{
+			name:  "header regex",
+			match: MatchConfig{Headers: map[string]ValueMatch{"Authorization": {Regex: "^Bearer .+"}}},
+			path:  "/",
+			setup: func(req *http.Request) { req.Header.Set("Authorization", "Bearer token") },
+			want:  true,
+		},
+		{
+			name:  "header regex differs",
+			match: MatchConfig{Headers: map[string]ValueMatch{"Authorization": {Regex: "^Bearer .+"}}},
+			path:  "/",
+			setup: func(req *http.Request) { req.Header.Set("Authorization", "Basic dXNlcg==") },
+		},
+		{
+			name:  "header values are joined",
+			match: MatchConfig{Headers: map[string]ValueMatch{"Accept": {Equals: "text/plain,application/json"}}},
+			path:  "/",
+			setup: func(req *http.Request) {
+				req.Header.Add("Accept", "text/plain")
+				req.Header.Add("Accept", "application/json")
+			},
+			want: true,
+		},
+		{
+			name:  "cookie equals",
+			match: MatchConfig{Cookies: map[string]ValueMatch{"session": {Equals: "abc"}}},
+			path:  "/",
+			setup: func(req *http.Request) { req.AddCookie(&http.Cookie{Name: "session", Value: "abc"}) },
+			want:  true,
+		},
+		{
+			name:  "cookie missing",
+			match: MatchConfig{Cookies: map[string]ValueMatch{"session": {Equals: "abc"}}},
+			path:  "/",
+		},
+		{
+			name:  "body equals",
+			match: MatchConfig{Body: &BodyMatch{Equals: "ping"}},
+			path:  "/",
+			body:  "ping",
+			want:  true,
+		},
+		{
+			name:  "body regex",
+			match: MatchConfig{Body: &BodyMatch{Regex: `"priority":\s*"high"`}},
+			path:  "/",
+			body:  `{"priority": "high"}`,
+			want:  true,
+		},
+		{
+			name:  "body regex differs",
+			match: MatchConfig{Body: &BodyMatch{Regex: `"priority":\s*"high"`}},
+			path:  "/",
+			body:  `{"priority": "low"}`,
+		},
+		{
+			name: "body JSON paths",
+			match: MatchConfig{Body: &BodyMatch{JSONPath: []JSONPathMatch{
+				{Path: "$.user.name", Equals: "jane"},
+				{Path: "$.items[1].id", Equals: "2"},
+				{Path: "active", Equals: "true"},
+			}}},
+			path: "/",
+			body: `{"user": {"name": "jane"}, "items": [{"id": 1}, {"id": 2}], "active": true}`,
+			want: true,
+		},
+		{
+			name:  "body JSON path differs",
+			match: MatchConfig{Body: &BodyMatch{JSONPath: []JSONPathMatch{{Path: "$.items[1].id", Equals: "1"}}}},
+			path:  "/",
+			body:  `{"items": [{"id": 1}, {"id": 2}]}`,
+		},
+		{
+			name:  "body JSON path out of range",
+			match: MatchConfig{Body: &BodyMatch{JSONPath: []JSONPathMatch{{Path: "$.items[2].id", Equals: "2"}}}},
+			path:  "/",
+			body:  `{"items": [{"id": 1}, {"id": 2}]}`,
+		},
+		{
+			name:  "body JSON path of an invalid document",
+			match: MatchConfig{Body: &BodyMatch{JSONPath: []JSONPathMatch{{Path: "$.id", Equals: "1"}}}},
+			path:  "/",
+			body:  `{"id": 1`,
+		},
+		{
+			name: "all rules must match",
+			match: MatchConfig{
+				Query:   map[string]ValueMatch{"type": {Equals: "admin"}},
+				Headers: map[string]ValueMatch{"X-Tenant": {Equals: "a"}},
+			},
+			path:  "/?type=admin",
+			setup: func(req *http.Request) { req.Header.Set("X-Tenant", "b") },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", tt.path, nil)
+			if tt.setup != nil {
+				tt.setup(req)
+			}
+			if got := tt.match.Matches(req, []byte(tt.body)); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		match   MatchConfig
+		wantErr string
+	}{
+		{
+			name: "valid",
+			match: MatchConfig{
+				Headers: map[string]ValueMatch{"Authorization": {Regex: "^Bearer"}},
+				Body:    &BodyMatch{Regex: "ping", JSONPath: []JSONPathMatch{{Path: "$.items[0][1].id"}}},
+			},
+		},
+		{
+			name:    "invalid query regex",
+			match:   MatchConfig{Query: map[string]ValueMatch{"type": {Regex: "("}}},
+			wantErr: "invalid query match type",
+		},
+		{
+			name:    "invalid body regex",
+			match:   MatchConfig{Body: &BodyMatch{Regex: "["}},
+			wantErr: "invalid body match",
+		},
+		{
+			name:    "invalid JSON path index",
+			match:   MatchConfig{Body: &BodyMatch{JSONPath: []JSONPathMatch{{Path: "$.items[x]"}}}},
+			wantErr: `invalid index "x"`,
+		},
+		{
+			name:    "unterminated JSON path index",
+			match:   MatchConfig{Body: &BodyMatch{JSONPath: []JSONPathMatch{{Path: "$.items[0"}}}},
+			wantErr: "missing ']'",
+		},
+		{
+			name:    "empty JSON path key",
+			match:   MatchConfig{Body: &BodyMatch{JSONPath: []JSONPathMatch{{Path: "$.user..id"}}}},
+			wantErr: "empty key",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.match.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v, want none", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMethodConfigMatch(t *testing.T) {
+	method := MethodConfig{
+		Method:     "POST",
+		StatusCode: 200,
+		Body:       "default",
+		Responses: []ResponseConfig{
+			{Match: MatchConfig{Query: map[string]ValueMatch{"fail": {}}}, StatusCode: 500, Body: "failed"},
+			{Match: MatchConfig{Body: &BodyMatch{Equals: "ping"}}, StatusCode: 200, Body: "pong"},
+			{Match: MatchConfig{Body: &BodyMatch{Regex: "^p"}}, StatusCode: 200, Body: "p"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		body     string
+		wantBody string
+	}{
+		{name: "first variant", path: "/?fail", body: "ping", wantBody: "failed"},
+		{name: "variants in order", path: "/", body: "ping", wantBody: "pong"},
+		{name: "later variant", path: "/", body: "pang", wantBody: "p"},
+		{name: "no variant", path: "/", body: "other"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", tt.path, strings.NewReader(tt.body))
+			got, ok := method.Match(req)
+			if ok != (tt.wantBody != "") || got.Body != tt.wantBody {
+				t.Errorf("Match() = %q, %v, want %q", got.Body, ok, tt.wantBody)
+			}
+
+			// the body can still be read after matching
+			if body, _ := io.ReadAll(req.Body); string(body) != tt.body {
+				t.Errorf("request body = %q, want %q", body, tt.body)
+			}
+		})
+	}
+}
+
+func TestServeHTTPBodyLimit(t *testing.T) {
+	staticAPI := &StaticAPI{Path: "/echo", Methods: []MethodConfig{{
+		Method:     "POST",
+		StatusCode: 200,
+		Body:       "default",
+		Responses:  []ResponseConfig{{Match: MatchConfig{Body: &BodyMatch{Regex: "^ping"}}, StatusCode: 200, Body: "pong"}},
+	}}}
+	staticAPI.SetSupported()
+
+	mux := http.NewServeMux()
+	mux.Handle(staticAPI.Path, staticAPI)
+	s := &Server{cfg: config.Config{BodyLimit: 8}, mux: mux, journal: newJournal(0, 0), accessLog: &accessLog{}}
+
+	tests := []struct {
+		name     string
+		body     string
+		wantBody string
+	}{
+		{name: "within the limit", body: "ping", wantBody: "pong"},
+		{name: "at the limit", body: "ping1234", wantBody: "pong"},
+		{name: "beyond the limit", body: "ping12345", wantBody: "default"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			s.ServeHTTP(w, httptest.NewRequest("POST", "/echo", strings.NewReader(tt.body)))
+			if w.Body.String() != tt.wantBody {
+				t.Errorf("body = %q, want %q", w.Body, tt.wantBody)
+			}
+		})
+	}
+}
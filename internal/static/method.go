@@ -1,12 +1,115 @@
 package static
 
+import (
+	"fmt"
+
+	"golang.org/x/net/http/httpguts"
+)
+
 // MethodConfig for a specific endpoint
 type MethodConfig struct {
 	Method     string            `yaml:"method"`
 	StatusCode int               `yaml:"status-code"`
-	Body       string            `yaml:"body"`
-	Headers    map[string]string `yaml:"headers"`
+	Body       string            `yaml:"body,omitempty"`
+	Headers    map[string]string `yaml:"headers,omitempty"`
+
+	// BodyFile is read instead of Body, relative to STATICAPIS_PATH. Path parameters
+	// select files from a directory, e.g. "fixtures/users/{id}.json".
+	BodyFile string `yaml:"body-file,omitempty"`
+
+	// BodyEncoding "base64" decodes Body before it is written, for binary content
+	BodyEncoding string `yaml:"body-encoding,omitempty"`
+
+	// Responses are evaluated in order and the first one matching the request is
+	// returned. StatusCode, Body and Headers above are used when none match.
+	Responses []ResponseConfig `yaml:"responses,omitempty"`
+
+	// Sequence is returned instead of the default response, one response per request.
+	// SequenceMode decides what happens after the last one: "stick" (default) keeps
+	// returning it, "loop" starts over and "random" picks a response by weight.
+	Sequence     []ResponseConfig `yaml:"sequence,omitempty"`
+	SequenceMode string           `yaml:"sequence-mode,omitempty"`
+
+	// ExpandParams replaces "{name}" placeholders of the path parameters in body and header values
+	ExpandParams bool `yaml:"expand-params,omitempty"`
+
+	// Template enables rendering body and header values as Go templates with access to the request
+	Template bool `yaml:"template,omitempty"`
+
+	// Fault injects latency and failures into the responses
+	Fault *FaultConfig `yaml:"fault,omitempty"`
+
+	// Validation rejects requests not satisfying the given schemas
+	Validation *ValidationConfig `yaml:"validation,omitempty"`
+}
+
+// ResponseConfig is a response variant returned when the request satisfies all of its match rules
+type ResponseConfig struct {
+	Match      MatchConfig       `yaml:"match,omitempty"`
+	StatusCode int               `yaml:"status-code"`
+	Body       string            `yaml:"body,omitempty"`
+	Headers    map[string]string `yaml:"headers,omitempty"`
+
+	BodyFile     string `yaml:"body-file,omitempty"`
+	BodyEncoding string `yaml:"body-encoding,omitempty"`
+
+	// Weight of the response in a random sequence (default: 1)
+	Weight int `yaml:"weight,omitempty"`
+}
+
+// MatchConfig holds the rules a request must satisfy, keyed by query parameter,
+// header or cookie name. An empty MatchConfig matches every request.
+type MatchConfig struct {
+	Query   map[string]ValueMatch `yaml:"query,omitempty"`
+	Headers map[string]ValueMatch `yaml:"headers,omitempty"`
+	Cookies map[string]ValueMatch `yaml:"cookies,omitempty"`
+	Body    *BodyMatch            `yaml:"body,omitempty"`
+
+	ClientCertificate *ClientCertificateMatch `yaml:"client-certificate,omitempty"`
+}
+
+// ValueMatch matches a single value exactly or by regular expression.
+// If neither is set the value only has to be present.
+type ValueMatch struct {
+	Equals string `yaml:"equals,omitempty"`
+	Regex  string `yaml:"regex,omitempty"`
+}
+
+// BodyMatch matches the request body
+type BodyMatch struct {
+	Equals   string          `yaml:"equals,omitempty"`
+	Regex    string          `yaml:"regex,omitempty"`
+	JSONPath []JSONPathMatch `yaml:"json-path,omitempty"`
+}
+
+// ClientCertificateMatch matches the verified TLS client certificate of the request by its
+// common name, any of its organizational units or SANs, and its SHA-256 fingerprint
+type ClientCertificateMatch struct {
+	CommonName         *ValueMatch `yaml:"common-name,omitempty"`
+	OrganizationalUnit *ValueMatch `yaml:"organizational-unit,omitempty"`
+	SAN                *ValueMatch `yaml:"san,omitempty"`
+	Fingerprint        string      `yaml:"fingerprint,omitempty"`
+}
+
+// JSONPathMatch matches the value found at a path in a JSON request body, e.g. "$.user.id"
+type JSONPathMatch struct {
+	Path   string `yaml:"path"`
+	Equals string `yaml:"equals"`
 }
 
 // SupportedMethods lists the supported methods for a given Endpoint
 type SupportedMethods []string
+
+// validateHeaders checks the header names of all responses of the method.
+func (m *MethodConfig) validateHeaders() error {
+	responses := append([]ResponseConfig{m.DefaultResponse()}, m.Responses...)
+	responses = append(responses, m.Sequence...)
+	for _, response := range responses {
+		for name := range response.Headers {
+			if !httpguts.ValidHeaderFieldName(name) {
+				return fmt.Errorf("invalid header name: %q", name)
+			}
+		}
+	}
+	return nil
+}
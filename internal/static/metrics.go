@@ -0,0 +1,142 @@
+package static
+
+import (
+	"crypto/tls"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// metrics holds the Prometheus collectors of the server, served at /metrics on METRICS_PORT
+type metrics struct {
+	registry *prometheus.Registry
+
+	requests       *prometheus.CounterVec
+	duration       *prometheus.HistogramVec
+	reloads        prometheus.Counter
+	reloadErrors   prometheus.Counter
+	endpoints      prometheus.Gauge
+	lastReloadTime prometheus.Gauge
+	tlsReloads     prometheus.Counter
+	tlsErrors      prometheus.Counter
+	tlsExpiry      prometheus.Gauge
+}
+
+func newMetrics() *metrics {
+	m := &metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "static_requests_total",
+			Help: "Number of requests served by each StaticAPI, by method and status code.",
+		}, []string{"name", "host", "path", "method", "code"}),
+		// buckets reach beyond the default 10s to cover injected delays
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "static_request_duration_seconds",
+			Help:    "Duration of requests served by each StaticAPI, including injected delays.",
+			Buckets: []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60},
+		}, []string{"name", "host", "path", "method"}),
+		reloads: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "static_config_reloads_total",
+			Help: "Number of times the StaticAPIs were reloaded.",
+		}),
+		reloadErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "static_config_reload_failures_total",
+			Help: "Number of times loading the configuration failed.",
+		}),
+		endpoints: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "static_endpoints",
+			Help: "Number of StaticAPIs currently served.",
+		}),
+		lastReloadTime: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "static_config_last_reload_timestamp_seconds",
+			Help: "Unix time of the last reload of the StaticAPIs.",
+		}),
+		tlsReloads: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "static_tls_reloads_total",
+			Help: "Number of times the TLS certificates were loaded.",
+		}),
+		tlsErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "static_tls_reload_failures_total",
+			Help: "Number of times reloading the TLS certificates failed.",
+		}),
+		tlsExpiry: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "static_tls_certificate_expiry_timestamp_seconds",
+			Help: "Unix time the first of the served TLS certificates expires at.",
+		}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests,
+		m.duration,
+		m.reloads,
+		m.reloadErrors,
+		m.endpoints,
+		m.lastReloadTime,
+		m.tlsReloads,
+		m.tlsErrors,
+		m.tlsExpiry,
+	)
+	return m
+}
+
+// instrument counts and times the requests next serves for staticAPI.
+func (m *metrics) instrument(staticAPI *StaticAPI, next http.Handler) http.Handler {
+	labels := prometheus.Labels{"name": staticAPI.Name, "host": staticAPI.Host, "path": staticAPI.Path}
+	return promhttp.InstrumentHandlerDuration(m.duration.MustCurryWith(labels),
+		promhttp.InstrumentHandlerCounter(m.requests.MustCurryWith(labels), next))
+}
+
+// reloaded records a reload serving the given StaticAPIs, and drops the series of those no
+// longer served.
+func (m *metrics) reloaded(previous, endpoints []StaticAPI) {
+	type key struct{ name, host, path string }
+	served := map[key]bool{}
+	for _, e := range endpoints {
+		served[key{e.Name, e.Host, e.Path}] = true
+	}
+	for _, e := range previous {
+		if !served[key{e.Name, e.Host, e.Path}] {
+			labels := prometheus.Labels{"name": e.Name, "host": e.Host, "path": e.Path}
+			m.requests.DeletePartialMatch(labels)
+			m.duration.DeletePartialMatch(labels)
+		}
+	}
+
+	m.reloads.Inc()
+	m.endpoints.Set(float64(len(endpoints)))
+	m.lastReloadTime.SetToCurrentTime()
+}
+
+// loadFailed records a failure to load the configuration.
+func (m *metrics) loadFailed() {
+	m.reloadErrors.Inc()
+}
+
+// tlsLoaded records loading the TLS certificates.
+func (m *metrics) tlsLoaded(certificates []tls.Certificate) {
+	m.tlsReloads.Inc()
+
+	var expiry int64
+	for _, certificate := range certificates {
+		if certificate.Leaf != nil && (expiry == 0 || certificate.Leaf.NotAfter.Unix() < expiry) {
+			expiry = certificate.Leaf.NotAfter.Unix()
+		}
+	}
+	if expiry != 0 {
+		m.tlsExpiry.Set(float64(expiry))
+	}
+}
+
+// tlsLoadFailed records a failure to reload the TLS certificates.
+func (m *metrics) tlsLoadFailed() {
+	m.tlsErrors.Inc()
+}
+
+// handler serves the metrics in the Prometheus exposition format.
+func (m *metrics) handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,105 @@
+package static
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/antonjah/static/internal/config"
+)
+
+// scrape returns the metrics of s in the Prometheus exposition format.
+func scrape(t *testing.T, s *Server) string {
+	t.Helper()
+	w := httptest.NewRecorder()
+	s.metrics.handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("metrics status = %d", w.Code)
+	}
+	return w.Body.String()
+}
+
+func TestMetrics(t *testing.T) {
+	s := newTestServer(t, config.Config{}, `staticapis:
+- name: users
+  path: /users
+  methods:
+  - method: GET
+    status-code: 200
+  - method: POST
+    status-code: 201
+`)
+	if status, _ := serve(s, http.MethodPut, "/_static/apis/orders",
+		"path: /orders\nmethods:\n- method: GET\n  status-code: 200\n"); status != http.StatusCreated {
+		t.Fatalf("PUT status = %d, want %d", status, http.StatusCreated)
+	}
+
+	serve(s, http.MethodGet, "/users", "")
+	serve(s, http.MethodGet, "/users", "")
+	serve(s, http.MethodPost, "/users", "{}")
+	serve(s, http.MethodGet, "/orders", "")
+
+	metrics := scrape(t, s)
+	for _, series := range []string{
+		`static_requests_total{code="200",host="",method="get",name="users",path="/users"} 2`,
+		`static_requests_total{code="201",host="",method="post",name="users",path="/users"} 1`,
+		`static_request_duration_seconds_count{host="",method="get",name="users",path="/users"} 2`,
+		`static_requests_total{code="200",host="",method="get",name="orders",path="/orders"} 1`,
+		"static_endpoints 2",
+	} {
+		if !strings.Contains(metrics, series) {
+			t.Errorf("metrics do not contain %s", series)
+		}
+	}
+
+	// the series of StaticAPIs no longer served are dropped on reload
+	if status, _ := serve(s, http.MethodDelete, "/_static/apis/orders", ""); status != http.StatusNoContent {
+		t.Fatalf("DELETE status = %d, want %d", status, http.StatusNoContent)
+	}
+	metrics = scrape(t, s)
+	if strings.Contains(metrics, `name="orders"`) {
+		t.Error("metrics still contain the series of the deleted StaticAPI")
+	}
+	if !strings.Contains(metrics, `name="users"`) || !strings.Contains(metrics, "static_endpoints 1") {
+		t.Error("metrics do not contain the StaticAPIs still served")
+	}
+}
+
+func TestMetricsHosts(t *testing.T) {
+	a := StaticAPI{Name: "orders", Path: "/orders"}
+	b := StaticAPI{Name: "orders", Host: "a.example", Path: "/orders"}
+	c := StaticAPI{Name: "orders", Host: "b.example", Path: "/orders"}
+
+	m := newMetrics()
+	ok := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+	for _, e := range []StaticAPI{a, b, c} {
+		m.instrument(&e, ok).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/orders", nil))
+	}
+
+	tests := []struct {
+		name      string
+		endpoints []StaticAPI
+		want      int
+	}{
+		{name: "series per host", endpoints: []StaticAPI{a, b, c}, want: 3},
+		{name: "series of removed hosts dropped", endpoints: []StaticAPI{a, c}, want: 2},
+		{name: "series of the remaining host kept", endpoints: []StaticAPI{c}, want: 1},
+	}
+
+	previous := []StaticAPI{a, b, c}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m.reloaded(previous, tt.endpoints)
+			previous = tt.endpoints
+			if got := testutil.CollectAndCount(m.requests); got != tt.want {
+				t.Errorf("request series = %d, want %d", got, tt.want)
+			}
+			if got := testutil.CollectAndCount(m.duration); got != tt.want {
+				t.Errorf("duration series = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
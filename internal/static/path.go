@@ -0,0 +1,182 @@
+package static
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode"
+)
+
+// PathParams returns the values captured by the wildcards in the path template
+// of the endpoint, keyed by wildcard name.
+func (e *StaticAPI) PathParams(req *http.Request) map[string]string {
+	params := map[string]string{}
+	for _, name := range pathParamNames(e.Path) {
+		params[name] = req.PathValue(name)
+	}
+	return params
+}
+
+// pathParamNames returns the wildcard names used in a path template,
+// e.g. ["id", "rest"] for "/users/{id}/files/{rest...}".
+func pathParamNames(path string) []string {
+	var names []string
+	for _, segment := range strings.Split(path, "/") {
+		if name, ok := wildcardName(segment); ok && name != "$" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// wildcardName returns the name of a wildcard segment such as "{id}" or "{rest...}".
+func wildcardName(segment string) (string, bool) {
+	if !strings.HasPrefix(segment, "{") || !strings.HasSuffix(segment, "}") {
+		return "", false
+	}
+	return strings.TrimSuffix(segment[1:len(segment)-1], "..."), true
+}
+
+// validatePath checks that path is a valid path template.
+// A template consists of literal segments and wildcard segments: "{name}" matches
+// a single segment, "{name...}" matches the remainder of the path and "{$}" only
+// matches the end of a path with a trailing slash.
+func validatePath(path string) error {
+	if !strings.HasPrefix(path, "/") {
+		return fmt.Errorf("path %q must start with '/'", path)
+	}
+
+	seen := map[string]bool{}
+	segments := strings.Split(path[1:], "/")
+	for i, segment := range segments {
+		last := i == len(segments)-1
+
+		if !strings.ContainsAny(segment, "{}") {
+			continue
+		}
+
+		name, ok := wildcardName(segment)
+		if !ok {
+			return fmt.Errorf("path %q: wildcard %q must be a full path segment", path, segment)
+		}
+
+		if name == "$" {
+			if !last || segment != "{$}" {
+				return fmt.Errorf("path %q: {$} is only allowed at the end of a path", path)
+			}
+			continue
+		}
+
+		if strings.HasSuffix(segment, "...}") && !last {
+			return fmt.Errorf("path %q: %q is only allowed as the last segment", path, segment)
+		}
+
+		if !isIdentifier(name) {
+			return fmt.Errorf("path %q: invalid wildcard name %q", path, name)
+		}
+
+		if seen[name] {
+			return fmt.Errorf("path %q: duplicate wildcard name %q", path, name)
+		}
+		seen[name] = true
+	}
+
+	// let the mux have the final say on anything not covered above
+	return handle(http.NewServeMux(), path, http.NotFoundHandler())
+}
+
+// isIdentifier reports whether s is a valid Go identifier, as required for wildcard names.
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		if !unicode.IsLetter(r) && r != '_' && (i == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return true
+}
+
+// expandPathParams replaces "{name}" and "{name...}" placeholders in s with the
+// captured path parameter values.
+func expandPathParams(s string, params map[string]string) string {
+	if len(params) == 0 || !strings.Contains(s, "{") {
+		return s
+	}
+
+	oldnew := make([]string, 0, len(params)*4)
+	for name, value := range params {
+		oldnew = append(oldnew, "{"+name+"...}", value, "{"+name+"}", value)
+	}
+	return strings.NewReplacer(oldnew...).Replace(s)
+}
+
+// conflicting reports whether patterns a and b cannot be registered on the same mux, as
+// they match the same requests without either being more specific than the other.
+func conflicting(a, b string) bool {
+	mux := http.NewServeMux()
+	return handle(mux, a, http.NotFoundHandler()) == nil && handle(mux, b, http.NotFoundHandler()) != nil
+}
+
+// validateHost checks that host is a host name or IP address without a port, or empty
+// for StaticAPIs serving every host.
+func validateHost(host string) error {
+	if host == "" {
+		return nil
+	}
+	if strings.ContainsAny(host, "/:{} ") || strings.Trim(host, ".") != host {
+		return fmt.Errorf("invalid host %q", host)
+	}
+	if host != strings.ToLower(host) {
+		return fmt.Errorf("host %q must be lowercase", host)
+	}
+	return nil
+}
+
+// Pattern returns the mux pattern of a StaticAPI serving path for host, or for every host
+// if host is empty. Patterns with a host take precedence over those without.
+func Pattern(host, path string) string {
+	return host + path
+}
+
+// ConflictingPath returns the index of the first of paths that path conflicts with when
+// both are registered on the same mux, or -1 if it conflicts with none of them. Paths
+// may be patterns prefixed with a host.
+func ConflictingPath(path string, paths []string) int {
+	for i, other := range paths {
+		if conflicting(other, path) {
+			return i
+		}
+	}
+	return -1
+}
+
+// PatternSet accepts patterns in order the way the mux of the static service does,
+// rejecting a pattern that conflicts with one accepted before it.
+type PatternSet struct {
+	mux *http.ServeMux
+}
+
+// NewPatternSet returns an empty PatternSet.
+func NewPatternSet() *PatternSet {
+	return &PatternSet{mux: http.NewServeMux()}
+}
+
+// Add accepts pattern and reports whether it is valid and conflicts with none of the
+// patterns accepted before.
+func (p *PatternSet) Add(pattern string) bool {
+	return handle(p.mux, pattern, http.NotFoundHandler()) == nil
+}
+
+// handle registers handler for pattern on mux and turns the panic raised by
+// http.ServeMux for invalid or conflicting patterns into an error.
+func handle(mux *http.ServeMux, pattern string, handler http.Handler) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	mux.Handle(pattern, handler)
+	return nil
+}
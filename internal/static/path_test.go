@@ -0,0 +1,88 @@
+package static
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidatePath(t *testing.T) {
+	tests := []struct {
+		path    string
+		wantErr string
+	}{
+		{path: "/users"},
+		{path: "/users/{id}"},
+		{path: "/users/{id}/orders/{orderId}"},
+		{path: "/files/{rest...}"},
+		{path: "/users/{$}"},
+		{path: "users", wantErr: "must start with '/'"},
+		{path: "/users/id{id}", wantErr: "must be a full path segment"},
+		{path: "/users/{$}/orders", wantErr: "{$} is only allowed at the end"},
+		{path: "/files/{rest...}/name", wantErr: "only allowed as the last segment"},
+		{path: "/users/{1d}", wantErr: "invalid wildcard name"},
+		{path: "/users/{id}/orders/{id}", wantErr: "duplicate wildcard name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			err := validatePath(tt.path)
+			if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConflicting(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{a: "/users/{id}", b: "/users/{name}", want: true},
+		{a: "/users/{id}", b: "/users/{id}", want: true},
+		{a: "/{kind}/1", b: "/users/{id}", want: true},
+		{a: "/users/{id}", b: "/users/me"},
+		{a: "/users/{id}", b: "/users/{id}/orders"},
+		{a: "/files/{rest...}", b: "/files/{name}"},
+		{a: "/users", b: "/orders"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+" "+tt.b, func(t *testing.T) {
+			if got := conflicting(tt.a, tt.b); got != tt.want {
+				t.Errorf("conflicting = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPathParams(t *testing.T) {
+	tests := []struct {
+		path   string
+		target string
+		want   map[string]string
+	}{
+		{path: "/users", target: "/users", want: map[string]string{}},
+		{path: "/users/{id}", target: "/users/42", want: map[string]string{"id": "42"}},
+		{path: "/users/{id}/files/{rest...}", target: "/users/42/files/a/b", want: map[string]string{"id": "42", "rest": "a/b"}},
+		{path: "/users/{$}", target: "/users/", want: map[string]string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			var got map[string]string
+			staticAPI := &StaticAPI{Path: tt.path}
+			mux := http.NewServeMux()
+			mux.HandleFunc(tt.path, func(_ http.ResponseWriter, req *http.Request) {
+				got = staticAPI.PathParams(req)
+			})
+			mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", tt.target, nil))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PathParams = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
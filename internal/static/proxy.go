@@ -0,0 +1,115 @@
+package static
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sort"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.uber.org/zap"
+)
+
+// upstream reverse proxies the requests below a path prefix to a base URL
+type upstream struct {
+	prefix string
+	target *url.URL
+	proxy  *httputil.ReverseProxy
+}
+
+// upstreams proxies requests not matching any StaticAPI to the upstream with the
+// longest matching path prefix. Requests matching none of them get a 404.
+type upstreams []upstream
+
+// newUpstreams creates the upstreams for the default base URL, used for all paths,
+// and for the base URLs keyed by path prefix. Either may be empty.
+func newUpstreams(defaultURL string, prefixes map[string]string) (upstreams, error) {
+	all := map[string]string{}
+	maps.Copy(all, prefixes)
+	if defaultURL != "" {
+		all["/"] = defaultURL
+	}
+
+	u := make(upstreams, 0, len(all))
+	for prefix, rawURL := range all {
+		if !strings.HasPrefix(prefix, "/") {
+			return nil, fmt.Errorf("invalid upstream prefix %q: must start with '/'", prefix)
+		}
+		target, err := parseUpstream(rawURL)
+		if err != nil {
+			return nil, err
+		}
+		u = append(u, upstream{prefix: prefix, target: target, proxy: newReverseProxy(target)})
+	}
+
+	sort.Slice(u, func(i, j int) bool {
+		return len(u[i].prefix) > len(u[j].prefix)
+	})
+	return u, nil
+}
+
+// ServeHTTP proxies the request to the upstream with the longest matching prefix.
+func (u upstreams) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	for _, upstream := range u {
+		if hasPathPrefix(r.URL.Path, upstream.prefix) {
+			markProxied(r, upstream.target)
+			upstream.proxy.ServeHTTP(w, r)
+			return
+		}
+	}
+	http.NotFound(w, r)
+}
+
+// hasPathPrefix reports whether path is prefix or lies below it, e.g. /api/users
+// lies below /api, but /apis does not.
+func hasPathPrefix(path, prefix string) bool {
+	if prefix == "/" || path == prefix {
+		return true
+	}
+	return strings.HasPrefix(path, strings.TrimSuffix(prefix, "/")+"/")
+}
+
+// parseUpstream parses an upstream base URL.
+func parseUpstream(rawURL string) (*url.URL, error) {
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid upstream %q: %w", rawURL, err)
+	}
+	if target.Scheme != "http" && target.Scheme != "https" || target.Host == "" {
+		return nil, fmt.Errorf("invalid upstream %q: must be an absolute http or https URL", rawURL)
+	}
+	return target, nil
+}
+
+// newReverseProxy creates a reverse proxy appending the request path to the target base URL.
+func newReverseProxy(target *url.URL) *httputil.ReverseProxy {
+	return &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(target)
+			pr.SetXForwarded()
+			// continue the trace of the request span, if tracing is enabled
+			otel.GetTextMapPropagator().Inject(pr.In.Context(), propagation.HeaderCarrier(pr.Out.Header))
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			zap.L().Error("failed to proxy request",
+				zap.String("upstream", target.String()),
+				zap.String("path", r.URL.Path),
+				zap.Error(err))
+			w.WriteHeader(http.StatusBadGateway)
+		},
+	}
+}
+
+// markProxied marks the request as proxied to target in the journal and the access log.
+func markProxied(r *http.Request, target *url.URL) {
+	if entry, ok := r.Context().Value(accessEntryKey{}).(*accessEntry); ok {
+		entry.Upstream = target.String()
+	}
+	if entry, ok := r.Context().Value(journalEntryKey{}).(*JournalEntry); ok {
+		entry.Upstream = target.String()
+	}
+}
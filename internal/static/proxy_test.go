@@ -0,0 +1,89 @@
+package static
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestUpstreams(t *testing.T) {
+	// every upstream answers with its name, the path it received and the forwarded host
+	newUpstream := func(name string) string {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, name+" "+r.URL.RequestURI()+" "+r.Header.Get("X-Forwarded-Host"))
+		}))
+		t.Cleanup(server.Close)
+		return server.URL
+	}
+	unreachable := httptest.NewServer(http.NotFoundHandler())
+	unreachable.Close()
+
+	u, err := newUpstreams(newUpstream("default")+"/base", map[string]string{
+		"/api":     newUpstream("api"),
+		"/api/v2/": newUpstream("v2"),
+		"/down":    unreachable.URL,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	prefixed, err := newUpstreams("", map[string]string{"/api": newUpstream("api")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		upstreams  upstreams
+		target     string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "longest prefix", upstreams: u, target: "/api/v2/users?page=2", wantStatus: 200, wantBody: "v2 /api/v2/users?page=2 example.com"},
+		{name: "prefix with trailing slash", upstreams: u, target: "/api/v2/", wantStatus: 200, wantBody: "v2 /api/v2/ example.com"},
+		{name: "shorter prefix", upstreams: u, target: "/api/v1/users", wantStatus: 200, wantBody: "api /api/v1/users example.com"},
+		{name: "prefix itself", upstreams: u, target: "/api", wantStatus: 200, wantBody: "api /api example.com"},
+		{name: "prefix of a path segment", upstreams: u, target: "/apis", wantStatus: 200, wantBody: "default /base/apis example.com"},
+		{name: "default upstream", upstreams: u, target: "/other", wantStatus: 200, wantBody: "default /base/other example.com"},
+		{name: "unreachable upstream", upstreams: u, target: "/down/users", wantStatus: 502},
+		{name: "no default upstream", upstreams: prefixed, target: "/other", wantStatus: 404, wantBody: "404 page not found\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			tt.upstreams.ServeHTTP(w, httptest.NewRequest("GET", tt.target, nil))
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if w.Body.String() != tt.wantBody {
+				t.Errorf("body = %q, want %q", w.Body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestNewUpstreams(t *testing.T) {
+	tests := []struct {
+		name       string
+		defaultURL string
+		prefixes   map[string]string
+		wantErr    string
+	}{
+		{name: "default only", defaultURL: "http://upstream"},
+		{name: "prefixes only", prefixes: map[string]string{"/api": "https://api"}},
+		{name: "relative prefix", prefixes: map[string]string{"api": "http://api"}, wantErr: "must start with '/'"},
+		{name: "relative default", defaultURL: "upstream", wantErr: "must be an absolute http or https URL"},
+		{name: "unsupported scheme", prefixes: map[string]string{"/api": "ftp://api"}, wantErr: "must be an absolute http or https URL"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newUpstreams(tt.defaultURL, tt.prefixes)
+			if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
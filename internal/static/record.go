@@ -0,0 +1,263 @@
+package static
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+	"golang.org/x/exp/slices"
+)
+
+// recorder forwards requests to an upstream and records every unique request/response
+// pair as a StaticAPI, rewriting the output file after each new recording.
+type recorder struct {
+	target *url.URL
+	proxy  *httputil.ReverseProxy
+	output string
+	format string
+
+	mu         sync.Mutex
+	staticAPIs []StaticAPI
+	seen       map[string]bool
+}
+
+// exchangeKey is the context key of the exchange being recorded
+type exchangeKey struct{}
+
+// exchange is the part of a proxied request that is recorded
+type exchange struct {
+	method string
+	path   string
+	query  url.Values
+	body   []byte
+}
+
+// skippedHeaders are response headers that are not recorded
+var skippedHeaders = []string{"Content-Length", "Date"}
+
+// newRecorder creates a recorder proxying to the upstream base URL and writing
+// the recordings to output in the given format.
+func newRecorder(upstream, output, format string) (*recorder, error) {
+	target, err := parseUpstream(upstream)
+	if err != nil {
+		return nil, err
+	}
+	if format != FormatYAML && format != FormatCRD {
+		return nil, fmt.Errorf("invalid format %q, must be %s or %s", format, FormatYAML, FormatCRD)
+	}
+
+	r := &recorder{
+		target: target,
+		output: output,
+		format: format,
+		seen:   map[string]bool{},
+	}
+	r.proxy = newReverseProxy(target)
+	rewrite := r.proxy.Rewrite
+	r.proxy.Rewrite = func(pr *httputil.ProxyRequest) {
+		rewrite(pr)
+		// let the transport negotiate compression, so bodies are recorded decoded
+		pr.Out.Header.Del("Accept-Encoding")
+	}
+	r.proxy.ModifyResponse = r.modifyResponse
+	return r, nil
+}
+
+// ServeHTTP forwards the request to the upstream.
+func (r *recorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	ex := exchange{
+		method: req.Method,
+		path:   req.URL.Path,
+		query:  req.URL.Query(),
+		body:   readBody(req),
+	}
+	markProxied(req, r.target)
+	r.proxy.ServeHTTP(w, req.WithContext(context.WithValue(req.Context(), exchangeKey{}, ex)))
+}
+
+// modifyResponse records the upstream response before it is sent to the client.
+func (r *recorder) modifyResponse(resp *http.Response) error {
+	ex, ok := resp.Request.Context().Value(exchangeKey{}).(exchange)
+	if !ok {
+		return nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return fmt.Errorf("failed to read upstream response: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	headers := map[string]string{}
+	for name, values := range resp.Header {
+		if len(values) > 0 && !slices.Contains(skippedHeaders, name) {
+			headers[strings.ToLower(name)] = values[0]
+		}
+	}
+
+	response := ResponseConfig{
+		StatusCode: resp.StatusCode,
+		Body:       string(body),
+		Headers:    headers,
+	}
+
+	// keep binary bodies intact in the recordings
+	if !utf8.Valid(body) {
+		response.Body = base64.StdEncoding.EncodeToString(body)
+		response.BodyEncoding = EncodingBase64
+	}
+
+	r.record(ex, response)
+	return nil
+}
+
+// record adds the response to the StaticAPI of the request path, unless an identical
+// request was recorded before. Requests with a query or body are recorded as response
+// variants matching them; the first response of a method, or the response to a request
+// without query and body, becomes its default response.
+func (r *recorder) record(ex exchange, response ResponseConfig) {
+	key := ex.method + " " + ex.path + "?" + ex.query.Encode() + "\n" + string(ex.body)
+
+	// paths ending in a slash would match the whole subtree
+	path := ex.path
+	if strings.HasSuffix(path, "/") {
+		path += "{$}"
+	}
+	if err := validatePath(path); err != nil {
+		zap.L().Warn("path cannot be recorded", zap.String("path", ex.path), zap.Error(err))
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.seen[key] {
+		return
+	}
+	r.seen[key] = true
+
+	staticAPI := r.staticAPI(path)
+	method := methodConfig(staticAPI, ex.method)
+	isNew := method.StatusCode == 0
+
+	if len(ex.query) > 0 || len(ex.body) > 0 {
+		response.Match = matchExchange(ex)
+
+		// keep more specific variants first, so they are not shadowed by less specific ones
+		i := 0
+		for i < len(method.Responses) && matchRules(method.Responses[i].Match) >= matchRules(response.Match) {
+			i++
+		}
+		method.Responses = slices.Insert(method.Responses, i, response)
+	}
+	if isNew || response.Match.Query == nil && response.Match.Body == nil {
+		method.StatusCode = response.StatusCode
+		method.Body = response.Body
+		method.Headers = response.Headers
+		method.BodyEncoding = response.BodyEncoding
+	}
+
+	zap.L().Info("recorded response",
+		zap.String("method", ex.method),
+		zap.String("path", path),
+		zap.String("query", ex.query.Encode()),
+		zap.Int("status", response.StatusCode))
+
+	if err := r.write(); err != nil {
+		zap.L().Error("failed to write recordings", zap.String("file", r.output), zap.Error(err))
+	}
+}
+
+// staticAPI returns the recorded StaticAPI for path, adding it if needed. r.mu must be held.
+func (r *recorder) staticAPI(path string) *StaticAPI {
+	names := map[string]bool{}
+	for i := range r.staticAPIs {
+		if r.staticAPIs[i].Path == path {
+			return &r.staticAPIs[i]
+		}
+		names[r.staticAPIs[i].Name] = true
+	}
+
+	// paths such as /a-b and /a_b share a resource name
+	base := resourceName(path)
+	name := base
+	for n := 2; names[name]; n++ {
+		suffix := "-" + strconv.Itoa(n)
+		name = strings.TrimRight(base[:min(len(base), 63-len(suffix))], "-") + suffix
+	}
+
+	r.staticAPIs = append(r.staticAPIs, StaticAPI{Name: name, Path: path})
+	return &r.staticAPIs[len(r.staticAPIs)-1]
+}
+
+// methodConfig returns the method of staticAPI, adding it if needed.
+func methodConfig(staticAPI *StaticAPI, method string) *MethodConfig {
+	for i := range staticAPI.Methods {
+		if staticAPI.Methods[i].Method == method {
+			return &staticAPI.Methods[i]
+		}
+	}
+	staticAPI.Methods = append(staticAPI.Methods, MethodConfig{Method: method})
+	return &staticAPI.Methods[len(staticAPI.Methods)-1]
+}
+
+// matchExchange returns match rules for the query parameters and body of a recorded request.
+func matchExchange(ex exchange) MatchConfig {
+	var match MatchConfig
+	if len(ex.query) > 0 {
+		match.Query = make(map[string]ValueMatch, len(ex.query))
+		for name, values := range ex.query {
+			match.Query[name] = ValueMatch{Equals: values[0]}
+		}
+	}
+	if len(ex.body) > 0 {
+		match.Body = &BodyMatch{Equals: string(ex.body)}
+	}
+	return match
+}
+
+// matchRules returns the number of query parameters and body rules of a recorded match.
+func matchRules(match MatchConfig) int {
+	rules := len(match.Query)
+	if match.Body != nil {
+		rules++
+	}
+	return rules
+}
+
+// write replaces the output file with the current recordings. r.mu must be held.
+func (r *recorder) write() error {
+	// write to a temporary file first, so the output is never left half written
+	fh, err := os.CreateTemp(filepath.Dir(r.output), "."+filepath.Base(r.output)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(fh.Name())
+
+	if err := fh.Chmod(0o644); err != nil {
+		_ = fh.Close()
+		return err
+	}
+
+	if err := WriteStaticAPIs(fh, r.staticAPIs, r.format); err != nil {
+		_ = fh.Close()
+		return err
+	}
+	if err := fh.Close(); err != nil {
+		return err
+	}
+	return os.Rename(fh.Name(), r.output)
+}
@@ -0,0 +1,174 @@
+package static
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestRecorder(t *testing.T) {
+	type request struct {
+		method string
+		target string
+		body   string
+	}
+
+	// the upstream numbers its responses, so a recording replaced by a later one shows
+	upstream := func(t *testing.T) *httptest.Server {
+		n := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			n++
+			if r.URL.Path == "/binary" {
+				w.Header().Set("Content-Type", "application/octet-stream")
+				w.Write([]byte{0xff, 0xfe, byte(n)})
+				return
+			}
+			body, _ := io.ReadAll(r.Body)
+			w.Header().Set("Content-Type", "text/plain")
+			if r.Method == http.MethodPost {
+				w.WriteHeader(http.StatusCreated)
+			}
+			fmt.Fprintf(w, "%d %s %s %s", n, r.Method, r.URL.RequestURI(), body)
+		}))
+		t.Cleanup(server.Close)
+		return server
+	}
+	text := map[string]string{"content-type": "text/plain"}
+
+	tests := []struct {
+		name     string
+		requests []request
+		want     []StaticAPI
+	}{
+		{
+			name:     "default response",
+			requests: []request{{method: "GET", target: "/users"}},
+			want: []StaticAPI{{Name: "users", Path: "/users", Methods: []MethodConfig{
+				{Method: "GET", StatusCode: 200, Body: "1 GET /users ", Headers: text},
+			}}},
+		},
+		{
+			name:     "identical requests recorded once",
+			requests: []request{{method: "GET", target: "/users"}, {method: "GET", target: "/users"}},
+			want: []StaticAPI{{Name: "users", Path: "/users", Methods: []MethodConfig{
+				{Method: "GET", StatusCode: 200, Body: "1 GET /users ", Headers: text},
+			}}},
+		},
+		{
+			name: "query variants",
+			requests: []request{
+				{method: "GET", target: "/users?page=2"},
+				{method: "GET", target: "/users?page=2&size=10"},
+				{method: "GET", target: "/users"},
+			},
+			want: []StaticAPI{{Name: "users", Path: "/users", Methods: []MethodConfig{{
+				Method: "GET", StatusCode: 200, Body: "3 GET /users ", Headers: text,
+				Responses: []ResponseConfig{
+					{
+						Match:      MatchConfig{Query: map[string]ValueMatch{"page": {Equals: "2"}, "size": {Equals: "10"}}},
+						StatusCode: 200, Body: "2 GET /users?page=2&size=10 ", Headers: text,
+					},
+					{
+						Match:      MatchConfig{Query: map[string]ValueMatch{"page": {Equals: "2"}}},
+						StatusCode: 200, Body: "1 GET /users?page=2 ", Headers: text,
+					},
+				},
+			}}}},
+		},
+		{
+			name:     "body variants",
+			requests: []request{{method: "POST", target: "/users", body: `{"name":"alice"}`}},
+			want: []StaticAPI{{Name: "users", Path: "/users", Methods: []MethodConfig{{
+				Method: "POST", StatusCode: 201, Body: `1 POST /users {"name":"alice"}`, Headers: text,
+				Responses: []ResponseConfig{{
+					Match:      MatchConfig{Body: &BodyMatch{Equals: `{"name":"alice"}`}},
+					StatusCode: 201, Body: `1 POST /users {"name":"alice"}`, Headers: text,
+				}},
+			}}}},
+		},
+		{
+			name:     "binary bodies",
+			requests: []request{{method: "GET", target: "/binary"}},
+			want: []StaticAPI{{Name: "binary", Path: "/binary", Methods: []MethodConfig{{
+				Method: "GET", StatusCode: 200, Body: "//4B", BodyEncoding: EncodingBase64,
+				Headers: map[string]string{"content-type": "application/octet-stream"},
+			}}}},
+		},
+		{
+			name:     "paths ending in a slash",
+			requests: []request{{method: "GET", target: "/users/"}},
+			want: []StaticAPI{{Name: "users", Path: "/users/{$}", Methods: []MethodConfig{
+				{Method: "GET", StatusCode: 200, Body: "1 GET /users/ ", Headers: text},
+			}}},
+		},
+		{
+			name:     "paths sharing a name",
+			requests: []request{{method: "GET", target: "/a-b"}, {method: "GET", target: "/a_b"}},
+			want: []StaticAPI{
+				{Name: "a-b", Path: "/a-b", Methods: []MethodConfig{{Method: "GET", StatusCode: 200, Body: "1 GET /a-b ", Headers: text}}},
+				{Name: "a-b-2", Path: "/a_b", Methods: []MethodConfig{{Method: "GET", StatusCode: 200, Body: "2 GET /a_b ", Headers: text}}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := filepath.Join(t.TempDir(), "recordings.yaml")
+			r, err := newRecorder(upstream(t).URL, output, FormatYAML)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, req := range tt.requests {
+				w := httptest.NewRecorder()
+				r.ServeHTTP(w, httptest.NewRequest(req.method, req.target, strings.NewReader(req.body)))
+				if w.Code >= 300 {
+					t.Fatalf("%s %s: status = %d", req.method, req.target, w.Code)
+				}
+			}
+
+			data, err := os.ReadFile(output)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got StaticAPIs
+			if err := yaml.Unmarshal(data, &got); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got.StaticAPIs, tt.want) {
+				t.Errorf("recorded\n%s", data)
+			}
+		})
+	}
+}
+
+func TestNewRecorder(t *testing.T) {
+	tests := []struct {
+		name     string
+		upstream string
+		format   string
+		wantErr  string
+	}{
+		{name: "yaml", upstream: "http://upstream", format: FormatYAML},
+		{name: "crd", upstream: "https://upstream/base", format: FormatCRD},
+		{name: "relative upstream", upstream: "/upstream", format: FormatYAML, wantErr: "must be an absolute http or https URL"},
+		{name: "invalid format", upstream: "http://upstream", format: "json", wantErr: `invalid format "json"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newRecorder(tt.upstream, "recordings.yaml", tt.format)
+			if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package static
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"sync"
+)
+
+// Sequence modes
+const (
+	// SequenceStick returns the responses in order and keeps returning the last one
+	SequenceStick = "stick"
+	// SequenceLoop returns the responses in order and starts over after the last one
+	SequenceLoop = "loop"
+	// SequenceRandom returns a random response, picked by weight
+	SequenceRandom = "random"
+)
+
+// sequences tracks the position of every response sequence, keyed by endpoint and method.
+// It is shared by all endpoints of a Server. Reloads keep the positions of sequences
+// whose configuration did not change.
+type sequences struct {
+	mu      sync.Mutex
+	cursors map[string]cursor
+}
+
+// cursor is the position in a sequence, with the hash of the sequence configuration it belongs to
+type cursor struct {
+	hash     string
+	position int
+}
+
+// newSequences creates an empty set of sequence positions.
+func newSequences() *sequences {
+	return &sequences{cursors: map[string]cursor{}}
+}
+
+// next returns the index of the response to return for the sequence identified by key.
+func (s *sequences) next(key string, method *MethodConfig) int {
+	n := len(method.Sequence)
+
+	if method.SequenceMode == SequenceRandom {
+		return pickWeighted(method.Sequence)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.cursors[key]
+	if !ok {
+		c.hash = sequenceHash(method)
+	}
+
+	i := c.position
+	switch method.SequenceMode {
+	case SequenceLoop:
+		c.position = (i + 1) % n
+	default:
+		if i < n-1 {
+			c.position = i + 1
+		}
+	}
+	s.cursors[key] = c
+	return min(i, n-1)
+}
+
+// retain drops the positions of sequences that are no longer served by staticAPIs
+// or whose configuration changed, so that they start over from their first response.
+func (s *sequences) retain(staticAPIs []StaticAPI) {
+	hashes := map[string]string{}
+	for i := range staticAPIs {
+		for j := range staticAPIs[i].Methods {
+			method := &staticAPIs[i].Methods[j]
+			if len(method.Sequence) > 0 {
+				hashes[staticAPIs[i].sequenceKey(method)] = sequenceHash(method)
+			}
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, c := range s.cursors {
+		if hashes[key] != c.hash {
+			delete(s.cursors, key)
+		}
+	}
+}
+
+// reset moves all sequences back to their first response.
+func (s *sequences) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cursors = map[string]cursor{}
+}
+
+// sequenceHash identifies the configuration of the sequence of method.
+func sequenceHash(method *MethodConfig) string {
+	hash, _ := computeConfigHash([]any{method.Sequence, method.SequenceMode})
+	return hash
+}
+
+// sequenceKey identifies the sequence of method among those of all StaticAPIs, including
+// those on the same path for other hosts.
+func (e *StaticAPI) sequenceKey(method *MethodConfig) string {
+	return e.Pattern() + " " + method.Method
+}
+
+// pickWeighted returns the index of a random response, where each response is
+// picked with a probability proportional to its weight (1 if unset).
+func pickWeighted(responses []ResponseConfig) int {
+	total := 0
+	for _, response := range responses {
+		total += weight(response)
+	}
+
+	n := rand.IntN(total)
+	for i, response := range responses {
+		if n < weight(response) {
+			return i
+		}
+		n -= weight(response)
+	}
+	return len(responses) - 1
+}
+
+// weight returns the weight of a response in a random sequence.
+func weight(response ResponseConfig) int {
+	if response.Weight == 0 {
+		return 1
+	}
+	return response.Weight
+}
+
+// validateSequence checks the sequence mode and the responses of a sequence.
+func (m *MethodConfig) validateSequence() error {
+	switch m.SequenceMode {
+	case "", SequenceStick, SequenceLoop, SequenceRandom:
+	default:
+		return fmt.Errorf("invalid sequence-mode %q, must be one of %s, %s or %s",
+			m.SequenceMode, SequenceStick, SequenceLoop, SequenceRandom)
+	}
+
+	for i, response := range m.Sequence {
+		if response.StatusCode < 100 || response.StatusCode > 599 {
+			return fmt.Errorf("invalid status-code for sequence response %d: %d", i, response.StatusCode)
+		}
+		if response.Weight < 0 {
+			return fmt.Errorf("invalid weight for sequence response %d: %d", i, response.Weight)
+		}
+	}
+
+	return nil
+}
+
+// response returns the response for req: the first matching response variant,
+// otherwise the next response of the sequence, otherwise the default response.
+func (e *StaticAPI) response(method *MethodConfig, req *http.Request) ResponseConfig {
+	if response, ok := method.Match(req); ok {
+		return response
+	}
+
+	if len(method.Sequence) > 0 {
+		if e.sequences == nil {
+			return method.Sequence[0]
+		}
+		return method.Sequence[e.sequences.next(e.sequenceKey(method), method)]
+	}
+
+	return method.DefaultResponse()
+}
+
+// handleSequences resets all response sequences on DELETE.
+func (s *Server) handleSequences(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.sequences.reset()
+	w.WriteHeader(http.StatusNoContent)
+}
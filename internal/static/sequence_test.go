@@ -0,0 +1,215 @@
+package static
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// newSequenceAPI returns a StaticAPI serving GET /orders with a sequence of the status codes.
+func newSequenceAPI(mode string, statusCodes ...int) *StaticAPI {
+	method := MethodConfig{Method: "GET", StatusCode: 200, SequenceMode: mode}
+	for _, statusCode := range statusCodes {
+		method.Sequence = append(method.Sequence, ResponseConfig{StatusCode: statusCode})
+	}
+	return &StaticAPI{Path: "/orders", Methods: []MethodConfig{method}, sequences: newSequences()}
+}
+
+// nextStatusCodes returns the status codes of the next n responses of staticAPI.
+func nextStatusCodes(staticAPI *StaticAPI, n int) []int {
+	var statusCodes []int
+	for range n {
+		response := staticAPI.response(&staticAPI.Methods[0], httptest.NewRequest("GET", "/orders", nil))
+		statusCodes = append(statusCodes, response.StatusCode)
+	}
+	return statusCodes
+}
+
+func TestResponseSequenceModes(t *testing.T) {
+	tests := []struct {
+		name string
+		mode string
+		want []int
+	}{
+		{name: "stick by default", mode: "", want: []int{500, 503, 200, 200, 200}},
+		{name: "stick", mode: SequenceStick, want: []int{500, 503, 200, 200, 200}},
+		{name: "loop", mode: SequenceLoop, want: []int{500, 503, 200, 500, 503}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := nextStatusCodes(newSequenceAPI(tt.mode, 500, 503, 200), len(tt.want))
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("status codes = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResponseSequenceRandom(t *testing.T) {
+	staticAPI := newSequenceAPI(SequenceRandom, 200, 500)
+	staticAPI.Methods[0].Sequence[0].Weight = 3
+
+	counts := map[int]int{}
+	for _, statusCode := range nextStatusCodes(staticAPI, 4000) {
+		counts[statusCode]++
+	}
+
+	// 200 is picked with a probability of 3/4
+	if counts[200] < 2800 || counts[200] > 3200 || counts[200]+counts[500] != 4000 {
+		t.Errorf("counts = %v, want about 3000 of 200 and 1000 of 500", counts)
+	}
+}
+
+func TestResponseSequenceMatchFirst(t *testing.T) {
+	staticAPI := newSequenceAPI(SequenceStick, 500, 200)
+	staticAPI.Methods[0].Responses = []ResponseConfig{{
+		Match:      MatchConfig{Query: map[string]ValueMatch{"ok": {}}},
+		StatusCode: 204,
+	}}
+
+	response := staticAPI.response(&staticAPI.Methods[0], httptest.NewRequest("GET", "/orders?ok", nil))
+	if response.StatusCode != 204 {
+		t.Errorf("status code = %d, want the matching variant 204", response.StatusCode)
+	}
+	// the variant does not advance the sequence
+	if got := nextStatusCodes(staticAPI, 1); got[0] != 500 {
+		t.Errorf("status code = %d, want the first response of the sequence 500", got[0])
+	}
+}
+
+func TestResponseSequenceHosts(t *testing.T) {
+	// StaticAPIs on the same path for different hosts advance their own sequences
+	shared := newSequences()
+	a := newSequenceAPI(SequenceLoop, 200, 201, 202)
+	b := newSequenceAPI(SequenceLoop, 200, 201, 202)
+	b.Host = "a.example"
+	c := newSequenceAPI(SequenceLoop, 200, 201, 202)
+	c.Host = "b.example"
+	for _, staticAPI := range []*StaticAPI{a, b, c} {
+		staticAPI.sequences = shared
+	}
+
+	tests := []struct {
+		name      string
+		staticAPI *StaticAPI
+		want      int
+	}{
+		{name: "first of a", staticAPI: a, want: 200},
+		{name: "first of b", staticAPI: b, want: 200},
+		{name: "second of b", staticAPI: b, want: 201},
+		{name: "first of c", staticAPI: c, want: 200},
+		{name: "second of a", staticAPI: a, want: 201},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextStatusCodes(tt.staticAPI, 1); got[0] != tt.want {
+				t.Errorf("status code = %d, want %d", got[0], tt.want)
+			}
+		})
+	}
+}
+
+func TestSequencesRetain(t *testing.T) {
+	unchanged := newSequenceAPI(SequenceStick, 500, 200)
+	changed := newSequenceAPI(SequenceStick, 500, 200)
+	changed.Path = "/payments"
+	removed := newSequenceAPI(SequenceStick, 500, 200)
+	removed.Path = "/refunds"
+
+	shared := newSequences()
+	for _, staticAPI := range []*StaticAPI{unchanged, changed, removed} {
+		staticAPI.sequences = shared
+		nextStatusCodes(staticAPI, 1)
+	}
+
+	// reload with a different sequence for /payments and without /refunds
+	reloaded := *changed
+	reloaded.Methods = []MethodConfig{{Method: "GET", Sequence: []ResponseConfig{{StatusCode: 502}, {StatusCode: 200}}}}
+	shared.retain([]StaticAPI{*unchanged, reloaded})
+
+	if got := nextStatusCodes(unchanged, 1); got[0] != 200 {
+		t.Errorf("unchanged sequence status code = %d, want 200", got[0])
+	}
+	if got := nextStatusCodes(&reloaded, 1); got[0] != 502 {
+		t.Errorf("changed sequence status code = %d, want it to start over with 502", got[0])
+	}
+	if got := nextStatusCodes(removed, 1); got[0] != 500 {
+		t.Errorf("removed sequence status code = %d, want it to start over with 500", got[0])
+	}
+}
+
+func TestHandleSequences(t *testing.T) {
+	staticAPI := newSequenceAPI(SequenceStick, 500, 200)
+	s := &Server{sequences: staticAPI.sequences}
+	nextStatusCodes(staticAPI, 2)
+
+	tests := []struct {
+		name       string
+		method     string
+		wantStatus int
+		wantNext   int
+	}{
+		{name: "GET is not allowed", method: "GET", wantStatus: http.StatusMethodNotAllowed, wantNext: 200},
+		{name: "DELETE starts over", method: "DELETE", wantStatus: http.StatusNoContent, wantNext: 500},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			s.handleSequences(w, httptest.NewRequest(tt.method, "/_static/sequences", nil))
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := nextStatusCodes(staticAPI, 1); got[0] != tt.wantNext {
+				t.Errorf("next status code = %d, want %d", got[0], tt.wantNext)
+			}
+		})
+	}
+}
+
+func TestValidateSequence(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  MethodConfig
+		wantErr string
+	}{
+		{
+			name:   "valid",
+			method: MethodConfig{SequenceMode: SequenceRandom, Sequence: []ResponseConfig{{StatusCode: 200, Weight: 2}}},
+		},
+		{
+			name:    "invalid mode",
+			method:  MethodConfig{SequenceMode: "shuffle"},
+			wantErr: `invalid sequence-mode "shuffle"`,
+		},
+		{
+			name:    "invalid status code",
+			method:  MethodConfig{Sequence: []ResponseConfig{{StatusCode: 200}, {StatusCode: 99}}},
+			wantErr: "invalid status-code for sequence response 1: 99",
+		},
+		{
+			name:    "negative weight",
+			method:  MethodConfig{Sequence: []ResponseConfig{{StatusCode: 200, Weight: -1}}},
+			wantErr: "invalid weight for sequence response 0: -1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.method.validateSequence()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validateSequence() error = %v, want none", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validateSequence() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -4,24 +4,33 @@ import (
 	"context"
 	"crypto/sha256"
 	"crypto/tls"
-	"crypto/x509"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
 
 	"github.com/antonjah/static/internal/config"
+	"github.com/antonjah/static/internal/openapi/spec"
 	staticv1alpha1 "github.com/antonjah/static/pkg/apis/static/v1alpha1"
 	"github.com/fsnotify/fsnotify"
 	"go.uber.org/zap"
+	"golang.org/x/exp/slices"
 	"gopkg.in/yaml.v3"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/rest"
+	toolscache "k8s.io/client-go/tools/cache"
+	ctrlcache "sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -35,9 +44,25 @@ type Server struct {
 	mu             sync.RWMutex
 	server         *http.Server
 	k8sClient      client.Client
+	k8sCache       ctrlcache.Cache // Informer backed cache the Kubernetes client reads from
 	namespace      string
-	lastConfigHash string // Track configuration changes
-	endpoints      []StaticAPI // Track configured endpoints for info endpoint
+	namespaces     []string // Namespaces StaticAPIs are loaded from, starting with namespace
+	apiSelector    labels.Selector
+	lastConfigHash string        // Track configuration changes
+	endpoints      []StaticAPI   // Track configured endpoints for info endpoint
+	sourceAPIs     []StaticAPI   // StaticAPIs loaded from the file or Kubernetes
+	runtimeAPIs    []StaticAPI   // StaticAPIs managed through the admin API
+	sequences      *sequences    // Track positions of response sequences
+	journal        *journal      // Track received requests for verification
+	fallback       http.Handler  // Handle requests not matching any StaticAPI
+	validation     *validation   // Validate requests against an OpenAPI document
+	bodyFiles      []string      // Track body files read by the StaticAPIs for reloading
+	metrics        *metrics      // Prometheus metrics served on METRICS_PORT
+	tracing        *tracing      // Trace requests if configured
+	accessLog      *accessLog    // Log requests after their response
+	certificates   *certificates // Serve the TLS certificates if enabled
+	synced         chan struct{} // Closed once the initial configuration is loaded
+	syncOnce       sync.Once
 }
 
 // New creates a new Server instance with the given configuration.
@@ -47,10 +72,81 @@ func New(cfg config.Config) *Server {
 		cfg:       cfg,
 		mux:       http.NewServeMux(),
 		namespace: cfg.Namespace,
+		sequences: newSequences(),
+		synced:    make(chan struct{}),
+		journal:   newJournal(cfg.JournalSize, cfg.JournalBodyLimit),
+		metrics:   newMetrics(),
+		validation: &validation{
+			statusCode: cfg.ValidationStatus,
+		},
+	}
+
+	tracing, err := newTracing(context.Background(), cfg.TracingEndpoint, cfg.TraceResponseHeaders)
+	if err != nil {
+		zap.L().Fatal("failed to initialize tracing", zap.Error(err))
+	}
+	server.tracing = tracing
+
+	// the health endpoints are served before the configuration is loaded
+	server.registerAdmin(server.mux)
+
+	if server.accessLog, err = newAccessLog(cfg.AccessLog, cfg.AccessLogFormat); err != nil {
+		zap.L().Fatal("failed to initialize access log", zap.Error(err))
+	}
+
+	if cfg.ValidationStatus < 100 || cfg.ValidationStatus > 599 {
+		zap.L().Fatal("invalid validation status", zap.Int("status", cfg.ValidationStatus))
+	}
+
+	// Validate requests against an OpenAPI document if configured
+	if cfg.ValidationOpenAPI != "" {
+		data, err := os.ReadFile(cfg.ValidationOpenAPI)
+		if err != nil {
+			zap.L().Fatal("failed to read OpenAPI document", zap.Error(err))
+		}
+		if server.validation.openapi, err = spec.Parse(data); err != nil {
+			zap.L().Fatal("failed to parse OpenAPI document", zap.Error(err))
+		}
+		zap.L().Info("validating requests", zap.String("openapi", cfg.ValidationOpenAPI))
+	}
+
+	// Record unmatched requests from an upstream if configured
+	if cfg.RecordUpstream != "" {
+		recorder, err := newRecorder(cfg.RecordUpstream, cfg.RecordOutput, cfg.RecordFormat)
+		if err != nil {
+			zap.L().Fatal("failed to initialize recorder", zap.Error(err))
+		}
+		server.fallback = recorder
+		zap.L().Info("recording unmatched requests",
+			zap.String("upstream", cfg.RecordUpstream),
+			zap.String("output", cfg.RecordOutput),
+			zap.String("format", cfg.RecordFormat))
+	} else if cfg.UpstreamURL != "" || len(cfg.Upstreams) > 0 {
+		upstreams, err := newUpstreams(cfg.UpstreamURL, cfg.Upstreams)
+		if err != nil {
+			zap.L().Fatal("failed to initialize upstreams", zap.Error(err))
+		}
+		server.fallback = upstreams
+		zap.L().Info("proxying unmatched requests",
+			zap.String("upstream", cfg.UpstreamURL),
+			zap.Any("upstreams", cfg.Upstreams))
 	}
 
 	// Initialize Kubernetes client if in cluster mode
 	if cfg.InCluster {
+		server.namespaces = []string{cfg.Namespace}
+		for _, namespace := range cfg.APINamespaces {
+			if namespace = strings.TrimSpace(namespace); namespace != "" && !slices.Contains(server.namespaces, namespace) {
+				server.namespaces = append(server.namespaces, namespace)
+			}
+		}
+
+		selector, err := labels.Parse(cfg.APISelector)
+		if err != nil {
+			zap.L().Fatal("invalid StaticAPI selector", zap.String("selector", cfg.APISelector), zap.Error(err))
+		}
+		server.apiSelector = selector
+
 		if err := server.initKubernetesClient(); err != nil {
 			zap.L().Fatal("failed to initialize Kubernetes client", zap.Error(err))
 		}
@@ -70,14 +166,42 @@ func (s *Server) initKubernetesClient() error {
 	if err := staticv1alpha1.AddToScheme(scheme); err != nil {
 		return fmt.Errorf("failed to add scheme: %w", err)
 	}
+	if err := corev1.AddToScheme(scheme); err != nil {
+		return fmt.Errorf("failed to add scheme: %w", err)
+	}
 
-	k8sClient, err := client.New(restConfig, client.Options{Scheme: scheme})
+	// Watch the namespaces through informers rather than listing them on every change,
+	// caching only the ConfigMaps and Secrets that opted in to be read from
+	namespaces := map[string]ctrlcache.Config{}
+	for _, namespace := range s.namespaces {
+		namespaces[namespace] = ctrlcache.Config{}
+	}
+	bodySources := labels.SelectorFromSet(labels.Set{staticv1alpha1.BodySourceLabel: "true"})
+	k8sCache, err := ctrlcache.New(restConfig, ctrlcache.Options{
+		Scheme:            scheme,
+		DefaultNamespaces: namespaces,
+		ByObject: map[client.Object]ctrlcache.ByObject{
+			&corev1.ConfigMap{}: {Label: bodySources},
+			&corev1.Secret{}:    {Label: bodySources},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes cache: %w", err)
+	}
+
+	k8sClient, err := client.New(restConfig, client.Options{
+		Scheme: scheme,
+		Cache:  &client.CacheOptions{Reader: k8sCache},
+	})
 	if err != nil {
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
 
+	s.k8sCache = k8sCache
 	s.k8sClient = k8sClient
-	zap.L().Info("Kubernetes client initialized", zap.String("namespace", s.namespace))
+	zap.L().Info("Kubernetes client initialized",
+		zap.Strings("namespaces", s.namespaces),
+		zap.String("selector", s.apiSelector.String()))
 	return nil
 }
 
@@ -85,12 +209,40 @@ func (s *Server) initKubernetesClient() error {
 
 func (s *Server) loadStaticAPIsFromK8s(ctx context.Context) error {
 	var staticAPIList staticv1alpha1.StaticAPIList
-	if err := s.k8sClient.List(ctx, &staticAPIList, client.InNamespace(s.namespace)); err != nil {
-		return fmt.Errorf("failed to list StaticAPIs: %w", err)
+	for _, namespace := range s.namespaces {
+		var namespaceList staticv1alpha1.StaticAPIList
+		if err := s.k8sClient.List(ctx, &namespaceList,
+			client.InNamespace(namespace),
+			client.MatchingLabelsSelector{Selector: s.apiSelector}); err != nil {
+			return fmt.Errorf("failed to list StaticAPIs in namespace %s: %w", namespace, err)
+		}
+		staticAPIList.Items = append(staticAPIList.Items, namespaceList.Items...)
 	}
 
-	// Compute hash of current configuration to detect changes
-	configHash, err := computeConfigHash(staticAPIList.Items)
+	// Load StaticAPIs in order of namespace and name, so the first of two conflicting ones is served
+	sort.Slice(staticAPIList.Items, func(i, j int) bool {
+		a, b := staticAPIList.Items[i], staticAPIList.Items[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+
+	// Read the bodies referenced from ConfigMaps and Secrets
+	sourceAPIs := make([]StaticAPI, 0, len(staticAPIList.Items))
+	bodies := map[string][]byte{}
+	var unresolved []error
+	for _, staticAPIObj := range staticAPIList.Items {
+		staticAPI := convertToStaticAPI(staticAPIObj)
+		if err := resolveBodySources(ctx, s.k8sClient, staticAPIObj, &staticAPI, bodies); err != nil {
+			unresolved = append(unresolved, fmt.Errorf("StaticAPI %s: %w", staticAPIObj.Name, err))
+			continue
+		}
+		sourceAPIs = append(sourceAPIs, staticAPI)
+	}
+
+	// Compute hash of current configuration, including the referenced bodies, to detect changes
+	configHash, err := computeConfigHash([]any{staticAPIList.Items, bodies})
 	if err != nil {
 		return fmt.Errorf("failed to compute config hash: %w", err)
 	}
@@ -100,33 +252,15 @@ func (s *Server) loadStaticAPIsFromK8s(ctx context.Context) error {
 		return nil
 	}
 
+	for _, err := range unresolved {
+		zap.L().Error("failed to resolve body", zap.Error(err))
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.mux = http.NewServeMux()
-	s.endpoints = []StaticAPI{} // Reset endpoints
-
-	for _, staticAPIObj := range staticAPIList.Items {
-		staticAPI := convertToStaticAPI(staticAPIObj)
-		if err := staticAPI.Validate(); err != nil {
-			zap.L().Error("validation failed",
-				zap.String("name", staticAPIObj.Name),
-				zap.String("path", staticAPI.Path),
-				zap.Error(err))
-			continue
-		}
-
-		staticAPI.SetSupported()
-		zap.L().Debug("loaded path",
-			zap.String("name", staticAPIObj.Name),
-			zap.String("path", staticAPI.Path),
-			zap.Any("methods", staticAPI.SupportedMethods))
-		s.mux.Handle(staticAPI.Path, requestLogger(&staticAPI))
-		s.endpoints = append(s.endpoints, staticAPI)
-	}
-
-	// Register info endpoint
-	s.mux.HandleFunc("/_static/info", s.handleInfo)
+	s.sourceAPIs = sourceAPIs
+	s.rebuild()
 
 	s.lastConfigHash = configHash
 	zap.L().Info("configuration loaded from Kubernetes", zap.Int("apis", len(staticAPIList.Items)))
@@ -134,7 +268,7 @@ func (s *Server) loadStaticAPIsFromK8s(ctx context.Context) error {
 }
 
 // computeConfigHash computes a SHA256 hash of the StaticAPI configuration.
-func computeConfigHash(items []staticv1alpha1.StaticAPI) (string, error) {
+func computeConfigHash(items any) (string, error) {
 	data, err := json.Marshal(items)
 	if err != nil {
 		return "", err
@@ -149,18 +283,175 @@ func convertToStaticAPI(obj staticv1alpha1.StaticAPI) StaticAPI {
 	methods := make([]MethodConfig, len(obj.Spec.Methods))
 	for i, m := range obj.Spec.Methods {
 		methods[i] = MethodConfig{
-			Method:     m.Method,
-			StatusCode: m.StatusCode,
-			Body:       m.Body,
-			Headers:    m.Headers,
+			Method:       m.Method,
+			StatusCode:   m.StatusCode,
+			Body:         m.Body,
+			Headers:      m.Headers,
+			BodyFile:     m.BodyFile,
+			BodyEncoding: m.BodyEncoding,
+			Responses:    convertResponses(m.Responses),
+			Sequence:     convertResponses(m.Sequence),
+			SequenceMode: m.SequenceMode,
+			ExpandParams: m.ExpandParams,
+			Template:     m.Template,
+			Fault:        convertFault(m.Fault),
+			Validation:   convertValidation(m.Validation),
 		}
 	}
 	return StaticAPI{
+		Name:    obj.Name,
+		Host:    obj.Spec.Host,
 		Path:    obj.Spec.Path,
 		Methods: methods,
+		source:  sourceKubernetes,
 	}
 }
 
+// ValidateStaticAPI validates a StaticAPI CRD the way the static service does when loading it.
+func ValidateStaticAPI(obj staticv1alpha1.StaticAPI) error {
+	staticAPI := convertToStaticAPI(obj)
+	return staticAPI.Validate()
+}
+
+// convertResponses converts the response variants of a StaticAPI CRD method.
+func convertResponses(responses []staticv1alpha1.Response) []ResponseConfig {
+	if len(responses) == 0 {
+		return nil
+	}
+
+	converted := make([]ResponseConfig, len(responses))
+	for i, r := range responses {
+		converted[i] = ResponseConfig{
+			Match: MatchConfig{
+				Query:   convertValueMatches(r.Match.Query),
+				Headers: convertValueMatches(r.Match.Headers),
+				Cookies: convertValueMatches(r.Match.Cookies),
+			},
+			StatusCode:   r.StatusCode,
+			Body:         r.Body,
+			Headers:      r.Headers,
+			BodyFile:     r.BodyFile,
+			BodyEncoding: r.BodyEncoding,
+			Weight:       r.Weight,
+		}
+
+		if b := r.Match.Body; b != nil {
+			body := &BodyMatch{Equals: b.Equals, Regex: b.Regex}
+			for _, j := range b.JSONPath {
+				body.JSONPath = append(body.JSONPath, JSONPathMatch{Path: j.Path, Equals: j.Equals})
+			}
+			converted[i].Match.Body = body
+		}
+
+		if c := r.Match.ClientCertificate; c != nil {
+			converted[i].Match.ClientCertificate = &ClientCertificateMatch{
+				CommonName:         convertValueMatch(c.CommonName),
+				OrganizationalUnit: convertValueMatch(c.OrganizationalUnit),
+				SAN:                convertValueMatch(c.SAN),
+				Fingerprint:        c.Fingerprint,
+			}
+		}
+	}
+	return converted
+}
+
+// convertValueMatch converts a single match rule of a StaticAPI CRD.
+func convertValueMatch(match *staticv1alpha1.ValueMatch) *ValueMatch {
+	if match == nil {
+		return nil
+	}
+	return &ValueMatch{Equals: match.Equals, Regex: match.Regex}
+}
+
+// convertFault converts the fault injection settings of a StaticAPI CRD method.
+func convertFault(f *staticv1alpha1.Fault) *FaultConfig {
+	if f == nil {
+		return nil
+	}
+
+	fault := &FaultConfig{
+		ErrorPercent:    f.ErrorPercent,
+		ErrorStatus:     f.ErrorStatus,
+		AbortPercent:    f.AbortPercent,
+		TruncatePercent: f.TruncatePercent,
+		Stall:           convertDuration(f.Stall),
+	}
+
+	if d := f.Delay; d != nil {
+		fault.Delay = &DelayConfig{
+			Fixed:        convertDuration(d.Fixed),
+			Distribution: d.Distribution,
+			Min:          convertDuration(d.Min),
+			Max:          convertDuration(d.Max),
+			Mean:         convertDuration(d.Mean),
+			StdDev:       convertDuration(d.StdDev),
+		}
+	}
+	return fault
+}
+
+// convertValidation converts the request validation schemas of a StaticAPI CRD method.
+// A schema that cannot be decoded makes the method fail validation.
+func convertValidation(v *staticv1alpha1.Validation) *ValidationConfig {
+	if v == nil {
+		return nil
+	}
+	config := &ValidationConfig{StatusCode: v.StatusCode}
+	var errs []error
+	for _, schema := range []struct {
+		name string
+		raw  *runtime.RawExtension
+		dst  **spec.Schema
+	}{
+		{"params", v.Params, &config.Params},
+		{"query", v.Query, &config.Query},
+		{"headers", v.Headers, &config.Headers},
+		{"body", v.Body, &config.Body},
+	} {
+		converted, err := convertSchema(schema.raw)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid validation %s schema: %w", schema.name, err))
+			continue
+		}
+		*schema.dst = converted
+	}
+	config.invalid = errors.Join(errs...)
+	return config
+}
+
+// convertSchema converts a JSON schema embedded in a StaticAPI CRD.
+func convertSchema(raw *runtime.RawExtension) (*spec.Schema, error) {
+	if raw == nil || len(raw.Raw) == 0 {
+		return nil, nil
+	}
+	var schema spec.Schema
+	if err := yaml.Unmarshal(raw.Raw, &schema); err != nil {
+		return nil, err
+	}
+	return &schema, nil
+}
+
+// convertDuration converts an optional Kubernetes duration.
+func convertDuration(d *metav1.Duration) Duration {
+	if d == nil {
+		return 0
+	}
+	return Duration(d.Duration)
+}
+
+// convertValueMatches converts query, header or cookie match rules of a StaticAPI CRD.
+func convertValueMatches(matches map[string]staticv1alpha1.ValueMatch) map[string]ValueMatch {
+	if len(matches) == 0 {
+		return nil
+	}
+
+	converted := make(map[string]ValueMatch, len(matches))
+	for name, m := range matches {
+		converted[name] = ValueMatch{Equals: m.Equals, Regex: m.Regex}
+	}
+	return converted
+}
+
 // loadStaticAPIsFromFile loads StaticAPI configuration from a YAML file.
 
 func (s *Server) loadStaticAPIsFromFile() error {
@@ -175,29 +466,160 @@ func (s *Server) loadStaticAPIsFromFile() error {
 		return err
 	}
 
+	// Compute hash of current configuration to detect changes
+	configHash, err := computeConfigHash(staticAPIs)
+	if err != nil {
+		return fmt.Errorf("failed to compute config hash: %w", err)
+	}
+
+	// Skip if configuration hasn't changed
+	if s.lastConfigHash == configHash {
+		return nil
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.mux = http.NewServeMux()
-	s.endpoints = []StaticAPI{} // Reset endpoints
+	for i := range staticAPIs.StaticAPIs {
+		staticAPIs.StaticAPIs[i].source = sourceFile
+	}
+	s.sourceAPIs = staticAPIs.StaticAPIs
+	s.rebuild()
+
+	s.lastConfigHash = configHash
+	zap.L().Info("configuration reloaded from file")
+	return nil
+}
+
+// rebuild registers the admin endpoints, the runtime StaticAPIs and the StaticAPIs
+// from the configuration source on a new mux, keeping the positions of response sequences
+// whose configuration did not change.
+// Runtime StaticAPIs take precedence over source StaticAPIs with the same path.
+// Invalid or conflicting StaticAPIs are logged and skipped. s.mu must be held.
+func (s *Server) rebuild() {
+	mux := http.NewServeMux()
+	endpoints := []StaticAPI{}
+	bodyFiles := []string{}
+
+	// Register admin endpoints
+	s.registerAdmin(mux)
+
+	overridden := map[string]bool{}
+	for _, staticAPI := range s.runtimeAPIs {
+		overridden[staticAPI.Pattern()] = true
+	}
 
-	for _, staticAPI := range staticAPIs.StaticAPIs {
-		if err = staticAPI.Validate(); err != nil {
-			zap.L().Error("validation failed", zap.String("path", staticAPI.Path), zap.Error(err))
+	for _, staticAPI := range append(slices.Clone(s.runtimeAPIs), s.sourceAPIs...) {
+		if staticAPI.source != sourceRuntime && overridden[staticAPI.Pattern()] {
+			zap.L().Debug("path overridden by runtime StaticAPI",
+				zap.String("name", staticAPI.Name),
+				zap.String("path", staticAPI.Path))
+			continue
+		}
+
+		staticAPI.sequences = s.sequences
+		staticAPI.validation = s.validation
+		staticAPI.bodyDir = s.cfg.StaticAPIsPath
+		if err := staticAPI.Validate(); err != nil {
+			zap.L().Error("validation failed",
+				zap.String("name", staticAPI.Name),
+				zap.String("path", staticAPI.Path),
+				zap.Error(err))
+			continue
+		}
+		if err := staticAPI.loadBodyFiles(); err != nil {
+			zap.L().Error("failed to read body file",
+				zap.String("name", staticAPI.Name),
+				zap.String("path", staticAPI.Path),
+				zap.Error(err))
 			continue
 		}
 
 		staticAPI.SetSupported()
-		zap.L().Debug("loaded path", zap.String("path", staticAPI.Path), zap.Any("methods", staticAPI.SupportedMethods))
-		s.mux.Handle(staticAPI.Path, requestLogger(&staticAPI))
-		s.endpoints = append(s.endpoints, staticAPI)
+		if err := staticAPI.conflict(endpoints); err != nil {
+			zap.L().Error("conflicting path",
+				zap.String("name", staticAPI.Name),
+				zap.String("path", staticAPI.Path),
+				zap.Error(err))
+			continue
+		}
+		if handle(mux, staticAPI.Pattern(), s.metrics.instrument(&staticAPI, traceStaticAPI(&staticAPI, logStaticAPI(&staticAPI, &staticAPI)))) != nil {
+			zap.L().Error("path conflicts with the /_static/ admin endpoints",
+				zap.String("name", staticAPI.Name),
+				zap.String("path", staticAPI.Path))
+			continue
+		}
+		zap.L().Debug("loaded path",
+			zap.String("name", staticAPI.Name),
+			zap.String("host", staticAPI.Host),
+			zap.String("path", staticAPI.Path),
+			zap.String("source", staticAPI.source),
+			zap.Any("methods", staticAPI.SupportedMethods))
+		endpoints = append(endpoints, staticAPI)
+		bodyFiles = append(bodyFiles, staticAPI.bodyFiles()...)
 	}
 
-	// Register info endpoint
-	s.mux.HandleFunc("/_static/info", s.handleInfo)
+	s.metrics.reloaded(s.endpoints, endpoints)
+	s.mux = mux
+	s.endpoints = endpoints
+	s.sequences.retain(endpoints)
+	s.bodyFiles = bodyFiles
+}
 
-	zap.L().Info("configuration reloaded from file")
-	return nil
+// reloadBodyFiles rebuilds the StaticAPIs so they read their body files again.
+func (s *Server) reloadBodyFiles() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rebuild()
+	zap.L().Info("body files reloaded")
+}
+
+// watchBodyFiles adds the directories of the body files read by the StaticAPIs to watcher
+// and returns the body files keyed by path. watched holds the directories already watched.
+func (s *Server) watchBodyFiles(watcher *fsnotify.Watcher, watched map[string]bool) map[string]bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	files := map[string]bool{}
+	for _, file := range s.bodyFiles {
+		files[file] = true
+
+		dir := filepath.Dir(file)
+		if watched[dir] {
+			continue
+		}
+		if err := watcher.Add(dir); err != nil {
+			zap.L().Error("failed to watch body file directory", zap.String("dir", dir), zap.Error(err))
+			continue
+		}
+		watched[dir] = true
+	}
+	return files
+}
+
+// latestModTime returns the latest modification time of files.
+func latestModTime(files map[string]bool) time.Time {
+	var latest time.Time
+	for file := range files {
+		if info, err := os.Stat(file); err == nil && info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest
+}
+
+// registerAdmin registers the /_static/ admin endpoints on mux.
+func (s *Server) registerAdmin(mux *http.ServeMux) {
+	mux.HandleFunc("/_static/healthz", s.handleHealthz)
+	mux.HandleFunc("/_static/readyz", s.handleReadyz)
+	mux.HandleFunc("/_static/info", s.handleInfo)
+	mux.HandleFunc("/_static/tls/ca.crt", s.handleCA)
+	mux.HandleFunc("/_static/sequences", s.handleSequences)
+	mux.HandleFunc("/_static/requests", s.handleRequests)
+	mux.HandleFunc("/_static/requests/count", s.handleRequestsCount)
+	mux.HandleFunc("/_static/apis", s.handleAPIs)
+	mux.HandleFunc("/_static/apis/{name}", s.handleAPI)
 }
 
 // ServeHTTP implements http.Handler.
@@ -205,35 +627,125 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	s.mu.RLock()
 	mux := s.mux
 	s.mu.RUnlock()
-	mux.ServeHTTP(w, r)
+
+	// proxied requests are streamed to the upstream, only limit the bodies read by the mux
+	handler := limitBody(mux, s.cfg.BodyLimit)
+	if s.fallback != nil {
+		handler = withFallback(mux, handler, s.fallback)
+	}
+	handler = s.journal.record(handler)
+	if s.tracing != nil {
+		handler = s.tracing.handler(handler)
+	}
+	handler = s.accessLog.record(handler)
+	handler.ServeHTTP(w, r)
 }
 
-// watchKubernetesAPIs polls for StaticAPI changes in Kubernetes every 5 seconds.
-func (s *Server) watchKubernetesAPIs(ctx context.Context) {
-	// Initial load
-	if err := s.loadStaticAPIsFromK8s(ctx); err != nil {
-		zap.L().Error("failed to load initial configuration", zap.Error(err))
+// limitBody limits the request bodies handler can read to limit bytes, unless limit is zero.
+func limitBody(handler http.Handler, limit int64) http.Handler {
+	if limit <= 0 {
+		return handler
 	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Body != nil {
+			r.Body = http.MaxBytesReader(w, r.Body, limit)
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
 
-	// Poll for changes every 5 seconds
-	// A more sophisticated implementation would use informers, but this is simpler
-	ticker := time.NewTicker(5 * time.Second)
-	defer ticker.Stop()
+// withFallback serves requests not matching any pattern of mux with fallback,
+// except those to the admin endpoints, and all others with handler.
+func withFallback(mux *http.ServeMux, handler, fallback http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, pattern := mux.Handler(r); pattern == "" && !strings.HasPrefix(r.URL.Path, "/_static/") {
+			fallback.ServeHTTP(w, r)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// Backoff of Kubernetes reloads after API errors
+const (
+	reloadBackoffMin = 100 * time.Millisecond
+	reloadBackoffMax = 30 * time.Second
+)
+
+// watchKubernetesAPIs loads the StaticAPIs once the informers have synced and reloads them
+// whenever a StaticAPI, ConfigMap or Secret in the namespaces changes. Changes arriving
+// during a reload are coalesced into one more reload, and failed loads are retried with
+// exponential backoff. The server is synced after the first successful load.
+func (s *Server) watchKubernetesAPIs(ctx context.Context, informers ctrlcache.Informers) {
+	changed := make(chan struct{}, 1)
+	notify := func() {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	}
+	handler := toolscache.ResourceEventHandlerFuncs{
+		AddFunc:    func(any) { notify() },
+		UpdateFunc: func(any, any) { notify() },
+		DeleteFunc: func(any) { notify() },
+	}
+
+	for _, obj := range []client.Object{&staticv1alpha1.StaticAPI{}, &corev1.ConfigMap{}, &corev1.Secret{}} {
+		informer, err := informers.GetInformer(ctx, obj, ctrlcache.BlockUntilSynced(false))
+		if err != nil {
+			zap.L().Fatal("failed to get informer", zap.String("type", fmt.Sprintf("%T", obj)), zap.Error(err))
+		}
+		if _, err := informer.AddEventHandler(handler); err != nil {
+			zap.L().Fatal("failed to add event handler", zap.String("type", fmt.Sprintf("%T", obj)), zap.Error(err))
+		}
+	}
 
-	zap.L().Info("polling for StaticAPI changes", zap.String("namespace", s.namespace))
+	zap.L().Info("watching for StaticAPI changes", zap.Strings("namespaces", s.namespaces))
 
+	if !informers.WaitForCacheSync(ctx) {
+		zap.L().Error("failed to sync Kubernetes cache")
+		return
+	}
+
+	var backoff time.Duration
 	for {
+		if err := s.loadStaticAPIsFromK8s(ctx); err != nil {
+			s.metrics.loadFailed()
+			backoff = min(max(2*backoff, reloadBackoffMin), reloadBackoffMax)
+			zap.L().Error("failed to load configuration, retrying",
+				zap.Duration("backoff", backoff),
+				zap.Error(err))
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			continue
+		}
+		backoff = 0
+		s.markSynced()
+
 		select {
 		case <-ctx.Done():
 			return
-		case <-ticker.C:
-			if err := s.loadStaticAPIsFromK8s(ctx); err != nil {
-				zap.L().Error("failed to reload configuration", zap.Error(err))
-			}
+		case <-changed:
 		}
 	}
 }
 
+// markSynced signals that the initial configuration has been loaded.
+func (s *Server) markSynced() {
+	s.syncOnce.Do(func() {
+		close(s.synced)
+		zap.L().Info("initial configuration loaded")
+	})
+}
+
+// Synced returns a channel that is closed once the initial configuration has been loaded.
+func (s *Server) Synced() <-chan struct{} {
+	return s.synced
+}
+
 // watchConfigFile watches the configuration file for changes and reloads when detected.
 func (s *Server) watchConfigFile(ctx context.Context) {
 	watcher, err := fsnotify.NewWatcher()
@@ -255,6 +767,11 @@ func (s *Server) watchConfigFile(ctx context.Context) {
 		lastModTime = info.ModTime()
 	}
 
+	// watch the files response bodies are read from as well
+	watched := map[string]bool{configDir: true}
+	bodyFiles := s.watchBodyFiles(watcher, watched)
+	lastBodyModTime := latestModTime(bodyFiles)
+
 	zap.L().Info("watching configuration file", zap.String("file", s.cfg.StaticAPIsFile))
 
 	for {
@@ -278,12 +795,24 @@ func (s *Server) watchConfigFile(ctx context.Context) {
 				time.Sleep(100 * time.Millisecond)
 				if err := s.loadStaticAPIsFromFile(); err != nil {
 					zap.L().Error("failed to reload configuration", zap.Error(err))
+					s.metrics.loadFailed()
 				} else {
 					if info, err := os.Stat(s.cfg.StaticAPIsFile); err == nil {
 						lastModTime = info.ModTime()
 					}
 				}
 			}
+
+			// body files mounted from a ConfigMap change along with its ..data link
+			if bodyFiles[filepath.Clean(event.Name)] || (eventBase == "..data" && len(bodyFiles) > 0) {
+				zap.L().Info("body file changed, reloading",
+					zap.String("file", event.Name),
+					zap.String("op", event.Op.String()))
+				time.Sleep(100 * time.Millisecond)
+				s.reloadBodyFiles()
+			}
+			bodyFiles = s.watchBodyFiles(watcher, watched)
+			lastBodyModTime = latestModTime(bodyFiles)
 		case <-ticker.C:
 			if info, err := os.Stat(s.cfg.StaticAPIsFile); err == nil {
 				if info.ModTime().After(lastModTime) {
@@ -291,9 +820,16 @@ func (s *Server) watchConfigFile(ctx context.Context) {
 					zap.L().Info("configuration file changed (poll), reloading")
 					if err := s.loadStaticAPIsFromFile(); err != nil {
 						zap.L().Error("failed to reload configuration", zap.Error(err))
+						s.metrics.loadFailed()
 					}
 				}
 			}
+			if modTime := latestModTime(bodyFiles); modTime.After(lastBodyModTime) {
+				lastBodyModTime = modTime
+				zap.L().Info("body file changed (poll), reloading")
+				s.reloadBodyFiles()
+			}
+			bodyFiles = s.watchBodyFiles(watcher, watched)
 		case err, ok := <-watcher.Errors:
 			if !ok {
 				return
@@ -316,14 +852,17 @@ func Run() {
 
 	// Load initial configuration
 	if cfg.InCluster {
-		if err := s.loadStaticAPIsFromK8s(ctx); err != nil {
-			zap.L().Fatal("failed to load initial configuration", zap.Error(err))
-		}
-		go s.watchKubernetesAPIs(ctx)
+		go func() {
+			if err := s.k8sCache.Start(ctx); err != nil {
+				zap.L().Fatal("failed to start Kubernetes cache", zap.Error(err))
+			}
+		}()
+		go s.watchKubernetesAPIs(ctx, s.k8sCache)
 	} else {
 		if err := s.loadStaticAPIsFromFile(); err != nil {
 			zap.L().Fatal("failed to load configuration", zap.Error(err))
 		}
+		s.markSynced()
 		go s.watchConfigFile(ctx)
 	}
 
@@ -334,24 +873,49 @@ func Run() {
 
 	if cfg.TLS {
 		verify := tls.NoClientCert
-		tlsConfig := &tls.Config{}
-
 		if cfg.VerifyClient {
 			verify = tls.RequireAndVerifyClientCert
 		}
 
-		if cfg.CA != "" {
-			caCert, err := os.ReadFile(cfg.CA)
-			if err != nil {
-				zap.L().Fatal("failed to read CA certificate", zap.Error(err))
-			}
-			caCertPool := x509.NewCertPool()
-			caCertPool.AppendCertsFromPEM(caCert)
-			tlsConfig.ClientCAs = caCertPool
+		// Serve the certificates through the TLS config, so they are reloaded when rotated
+		var err error
+		if len(cfg.Certificates) == 0 {
+			hosts := tlsHosts(cfg)
+			s.certificates, err = generateCertificates(hosts, s.metrics)
+			zap.L().Info("generated TLS certificates", zap.Strings("hosts", hosts))
+		} else {
+			s.certificates, err = newCertificates(cfg.Certificates, cfg.Keys, cfg.CA, s.metrics)
 		}
+		if err != nil {
+			zap.L().Fatal("failed to load TLS certificates", zap.Error(err))
+		}
+		s.server.TLSConfig = s.certificates.tlsConfig(verify)
+		go s.certificates.watch(ctx)
+	}
 
-		tlsConfig.ClientAuth = verify
-		s.server.TLSConfig = tlsConfig
+	// Serve metrics, health checks and the generated CA on a port of their own, so they are
+	// neither shadowed by a StaticAPI nor behind TLS client verification
+	var metricsServer *http.Server
+	if cfg.MetricsAddress != "" {
+		metricsMux := http.NewServeMux()
+		metricsMux.Handle("/metrics", s.metrics.handler())
+		metricsMux.HandleFunc("/_static/healthz", s.handleHealthz)
+		metricsMux.HandleFunc("/_static/readyz", s.handleReadyz)
+		metricsMux.HandleFunc("/_static/tls/ca.crt", s.handleCA)
+		// anyone reaching the issuer can pass client verification, so it is opt-in
+		if cfg.ClientIssuer {
+			metricsMux.HandleFunc("/_static/tls/client", s.handleClientCertificate)
+		}
+		metricsServer = &http.Server{
+			Addr:    cfg.MetricsAddress,
+			Handler: metricsMux,
+		}
+		go func() {
+			zap.L().Info("serving metrics", zap.String("address", cfg.MetricsAddress))
+			if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				zap.L().Fatal("metrics server error", zap.Error(err))
+			}
+		}()
 	}
 
 	go func() {
@@ -361,7 +925,7 @@ func Run() {
 			zap.String("address", cfg.Address))
 		var err error
 		if cfg.TLS {
-			err = s.server.ListenAndServeTLS(cfg.Certificate, cfg.Key)
+			err = s.server.ListenAndServeTLS("", "")
 		} else {
 			err = s.server.ListenAndServe()
 		}
@@ -383,4 +947,14 @@ func Run() {
 	if err := s.server.Shutdown(shutdownCtx); err != nil {
 		zap.L().Error("server shutdown error", zap.Error(err))
 	}
+	if metricsServer != nil {
+		if err := metricsServer.Shutdown(shutdownCtx); err != nil {
+			zap.L().Error("metrics server shutdown error", zap.Error(err))
+		}
+	}
+	if s.tracing != nil {
+		if err := s.tracing.shutdown(shutdownCtx); err != nil {
+			zap.L().Error("tracing shutdown error", zap.Error(err))
+		}
+	}
 }
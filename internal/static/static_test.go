@@ -0,0 +1,234 @@
+package static
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/antonjah/static/internal/config"
+	staticv1alpha1 "github.com/antonjah/static/pkg/apis/static/v1alpha1"
+	"golang.org/x/exp/slices"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache/informertest"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// newTestServer returns a Server with cfg serving the StaticAPIs of a staticapis.yaml document.
+// Settings New requires fall back to their environment defaults.
+func newTestServer(t *testing.T, cfg config.Config, staticAPIs string) *Server {
+	t.Helper()
+
+	file := filepath.Join(t.TempDir(), "staticapis.yaml")
+	if err := os.WriteFile(file, []byte(staticAPIs), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg.StaticAPIsFile = file
+	if cfg.ValidationStatus == 0 {
+		cfg.ValidationStatus = http.StatusBadRequest
+	}
+	if cfg.AccessLogFormat == "" {
+		cfg.AccessLogFormat = "json"
+	}
+	s := New(cfg)
+	if err := s.loadStaticAPIsFromFile(); err != nil {
+		t.Fatalf("load %s: %v", file, err)
+	}
+	return s
+}
+
+func TestValidateStaticAPISchema(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		wantErr string
+	}{
+		{name: "no schema"},
+		{name: "valid schema", body: `{"type": "object", "required": ["name"]}`},
+		{name: "invalid schema", body: `{"properties": ["name"]}`, wantErr: "invalid validation body schema"},
+		{name: "invalid pattern", body: `{"properties": {"name": {"type": "string", "pattern": "[a-z"}}}`, wantErr: `invalid validation body schema: $.name: invalid pattern "[a-z"`},
+		{name: "ref", body: `{"items": {"$ref": "#/components/schemas/User"}}`, wantErr: `invalid validation body schema: $[]: $ref "#/components/schemas/User" cannot be resolved`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validation := &staticv1alpha1.Validation{}
+			if tt.body != "" {
+				validation.Body = &runtime.RawExtension{Raw: []byte(tt.body)}
+			}
+			obj := staticv1alpha1.StaticAPI{Spec: staticv1alpha1.StaticAPISpec{
+				Path:    "/users",
+				Methods: []staticv1alpha1.Method{{Method: "POST", StatusCode: 201, Validation: validation}},
+			}}
+
+			err := ValidateStaticAPI(obj)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ValidateStaticAPI: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("ValidateStaticAPI = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWatchKubernetesAPIs(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := staticv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	staticAPI := &staticv1alpha1.StaticAPI{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "users"},
+		Spec: staticv1alpha1.StaticAPISpec{
+			Path:    "/users",
+			Methods: []staticv1alpha1.Method{{Method: "GET", StatusCode: 200}},
+		},
+	}
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(staticAPI).Build()
+	informers := &informertest.FakeInformers{Scheme: scheme}
+
+	server := New(config.Config{Namespace: "default", ValidationStatus: http.StatusBadRequest, AccessLogFormat: "json"})
+	server.namespaces = []string{"default"}
+	server.apiSelector = labels.Everything()
+	server.k8sClient = k8sClient
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if got, _ := serve(server, http.MethodGet, "/_static/readyz", ""); got != http.StatusServiceUnavailable {
+		t.Fatalf("readyz before sync = %d, want %d", got, http.StatusServiceUnavailable)
+	}
+
+	go server.watchKubernetesAPIs(ctx, informers)
+
+	select {
+	case <-server.Synced():
+	case <-time.After(5 * time.Second):
+		t.Fatal("configuration not loaded")
+	}
+	if got, _ := serve(server, http.MethodGet, "/_static/readyz", ""); got != http.StatusOK {
+		t.Fatalf("readyz after sync = %d, want %d", got, http.StatusOK)
+	}
+	if got, _ := serve(server, http.MethodGet, "/users", ""); got != http.StatusOK {
+		t.Fatalf("GET /users = %d, want %d", got, http.StatusOK)
+	}
+
+	// a change notified by the informer is applied without polling
+	updated := staticAPI.DeepCopy()
+	updated.Spec.Methods[0].StatusCode = http.StatusAccepted
+	if err := k8sClient.Update(ctx, updated); err != nil {
+		t.Fatal(err)
+	}
+	informer, err := informers.FakeInformerFor(ctx, &staticv1alpha1.StaticAPI{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	informer.Update(staticAPI, updated)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if status, _ := serve(server, http.MethodGet, "/users", ""); status == http.StatusAccepted {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("update not applied")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestLoadStaticAPIsFromK8sFiltering(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := staticv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	newObj := func(namespace, name, path string, labels map[string]string) *staticv1alpha1.StaticAPI {
+		return &staticv1alpha1.StaticAPI{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, Labels: labels},
+			Spec: staticv1alpha1.StaticAPISpec{
+				Path:    path,
+				Methods: []staticv1alpha1.Method{{Method: "GET", StatusCode: 200}},
+			},
+		}
+	}
+	payments := map[string]string{"team": "payments"}
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		newObj("default", "orders", "/orders", payments),
+		newObj("default", "unlabeled", "/unlabeled", nil),
+		newObj("team-a", "invoices", "/invoices", payments),
+		newObj("team-a", "other-team", "/other-team", map[string]string{"team": "search"}),
+		newObj("team-b", "refunds", "/refunds", payments),
+		// of two conflicting StaticAPIs the one whose namespace sorts first is served
+		newObj("default", "z-users", "/users/{id}", payments),
+		newObj("team-a", "a-users", "/users/{name}", payments),
+	).Build()
+
+	tests := []struct {
+		name       string
+		namespaces []string
+		selector   string
+		want       []string
+	}{
+		{
+			name:       "namespace",
+			namespaces: []string{"default"},
+			want:       []string{"/orders", "/unlabeled", "/users/{id}"},
+		},
+		{
+			name:       "extra namespaces",
+			namespaces: []string{"default", "team-a"},
+			want:       []string{"/orders", "/unlabeled", "/users/{id}", "/invoices", "/other-team"},
+		},
+		{
+			name:       "selector",
+			namespaces: []string{"default", "team-a"},
+			selector:   "team=payments",
+			want:       []string{"/orders", "/users/{id}", "/invoices"},
+		},
+		{
+			name:       "set based selector",
+			namespaces: []string{"team-a", "team-b"},
+			selector:   "team in (search)",
+			want:       []string{"/other-team"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selector, err := labels.Parse(tt.selector)
+			if err != nil {
+				t.Fatal(err)
+			}
+			server := New(config.Config{ValidationStatus: http.StatusBadRequest, AccessLogFormat: "json"})
+			server.namespaces = tt.namespaces
+			server.apiSelector = selector
+			server.k8sClient = k8sClient
+
+			if err := server.loadStaticAPIsFromK8s(context.Background()); err != nil {
+				t.Fatalf("loadStaticAPIsFromK8s: %v", err)
+			}
+			var got []string
+			for _, endpoint := range server.endpoints {
+				got = append(got, endpoint.Path)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("served paths = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package static
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// templateCache holds parsed response templates keyed by their source
+var templateCache = newCache[*template.Template](1024)
+
+// templateFuncs are the helper functions available to response templates
+var templateFuncs = template.FuncMap{
+	"now":      time.Now,
+	"uuid":     newUUID,
+	"randInt":  randInt,
+	"b64enc":   func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) },
+	"b64dec":   b64dec,
+	"toJSON":   toJSON,
+	"jsonPath": jsonPath,
+	"upper":    strings.ToUpper,
+	"lower":    strings.ToLower,
+}
+
+// templateData is the request data available to response templates, e.g.
+// {{ .Params.id }}, {{ .Query.Get "page" }}, {{ .Headers.Get "X-Request-Id" }}
+// or {{ jsonPath .JSON "$.user.id" }}.
+type templateData struct {
+	Method  string
+	Path    string
+	Params  map[string]string
+	Query   url.Values
+	Headers http.Header
+	Cookies map[string]string
+	Body    string
+	JSON    any
+}
+
+// newTemplateData collects the request data exposed to response templates.
+func newTemplateData(req *http.Request, params map[string]string) templateData {
+	body := readBody(req)
+
+	cookies := map[string]string{}
+	for _, cookie := range req.Cookies() {
+		cookies[cookie.Name] = cookie.Value
+	}
+
+	// keep numbers as json.Number so they render as sent rather than as floats
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var document any
+	_ = decoder.Decode(&document)
+
+	return templateData{
+		Method:  req.Method,
+		Path:    req.URL.Path,
+		Params:  params,
+		Query:   req.URL.Query(),
+		Headers: req.Header,
+		Cookies: cookies,
+		Body:    string(body),
+		JSON:    document,
+	}
+}
+
+// render returns the body and header values of response for req.
+// Path parameter placeholders are expanded if the method opts in with ExpandParams,
+// or when the method has templating enabled, body and header values are executed
+// as Go templates. Otherwise they are returned as configured.
+func (m *MethodConfig) render(response ResponseConfig, req *http.Request, params map[string]string) (string, map[string]string, error) {
+	headers := make(map[string]string, len(response.Headers))
+
+	if !m.Template {
+		if !m.ExpandParams {
+			params = nil
+		}
+		for key, val := range response.Headers {
+			headers[key] = expandPathParams(val, params)
+		}
+		return expandPathParams(response.Body, params), headers, nil
+	}
+
+	data := newTemplateData(req, params)
+
+	for key, val := range response.Headers {
+		rendered, err := executeTemplate(val, data)
+		if err != nil {
+			return "", nil, fmt.Errorf("header %s: %w", key, err)
+		}
+		headers[key] = rendered
+	}
+
+	body, err := executeTemplate(response.Body, data)
+	if err != nil {
+		return "", nil, fmt.Errorf("body: %w", err)
+	}
+
+	return body, headers, nil
+}
+
+// validateTemplates checks that the body and header values of all responses parse as templates.
+func (m *MethodConfig) validateTemplates() error {
+	responses := append([]ResponseConfig{m.DefaultResponse()}, m.Responses...)
+	responses = append(responses, m.Sequence...)
+	for _, response := range responses {
+		if _, err := parseTemplate(response.Body); err != nil {
+			return fmt.Errorf("invalid body template: %w", err)
+		}
+		for key, val := range response.Headers {
+			if _, err := parseTemplate(val); err != nil {
+				return fmt.Errorf("invalid template for header %s: %w", key, err)
+			}
+		}
+	}
+	return nil
+}
+
+// parseTemplate returns the parsed form of text, parsing it only once while it is cached.
+func parseTemplate(text string) (*template.Template, error) {
+	if tmpl, ok := templateCache.Load(text); ok {
+		return tmpl, nil
+	}
+	tmpl, err := template.New("response").Funcs(templateFuncs).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	templateCache.Store(text, tmpl)
+	return tmpl, nil
+}
+
+// executeTemplate parses text as a template and executes it with data.
+func executeTemplate(text string, data templateData) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+
+	tmpl, err := parseTemplate(text)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// newUUID returns a random (version 4) UUID.
+func newUUID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// randInt returns a random integer in [low, high).
+func randInt(low, high int) (int, error) {
+	if high <= low {
+		return 0, fmt.Errorf("randInt: %d must be greater than %d", high, low)
+	}
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(high-low)))
+	if err != nil {
+		return 0, err
+	}
+	return low + int(n.Int64()), nil
+}
+
+// b64dec decodes a standard base64 encoded string.
+func b64dec(s string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// toJSON returns the JSON encoding of v.
+func toJSON(v any) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// jsonPath returns the value found at path in a decoded JSON document, or nil if there is none.
+func jsonPath(document any, path string) any {
+	value, _ := lookupJSONPath(document, path)
+	return value
+}
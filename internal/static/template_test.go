@@ -0,0 +1,281 @@
+package static
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestRenderExpandParams(t *testing.T) {
+	params := map[string]string{"id": "42", "rest": "a/b"}
+
+	tests := []struct {
+		name       string
+		method     MethodConfig
+		body       string
+		header     string
+		wantBody   string
+		wantHeader string
+	}{
+		{
+			name:       "disabled by default",
+			body:       `{"id": "{id}"}`,
+			header:     "{id}",
+			wantBody:   `{"id": "{id}"}`,
+			wantHeader: "{id}",
+		},
+		{
+			name:       "enabled",
+			method:     MethodConfig{ExpandParams: true},
+			body:       `{"id": "{id}", "path": "{rest...}"}`,
+			header:     "{id}",
+			wantBody:   `{"id": "42", "path": "a/b"}`,
+			wantHeader: "42",
+		},
+		{
+			name:       "undeclared names are left alone",
+			method:     MethodConfig{ExpandParams: true},
+			body:       `{"id": "{id}", "other": "{name}"}`,
+			header:     "{name}",
+			wantBody:   `{"id": "42", "other": "{name}"}`,
+			wantHeader: "{name}",
+		},
+		{
+			name:       "templates ignore placeholders",
+			method:     MethodConfig{ExpandParams: true, Template: true},
+			body:       `{{ .Params.id }} {id}`,
+			header:     "{{ .Params.rest }}",
+			wantBody:   "42 {id}",
+			wantHeader: "a/b",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := ResponseConfig{Body: tt.body, Headers: map[string]string{"x-test": tt.header}}
+			req := httptest.NewRequest("GET", "/users/42", nil)
+
+			body, headers, err := tt.method.render(response, req, params)
+			if err != nil {
+				t.Fatalf("render: %v", err)
+			}
+			if body != tt.wantBody {
+				t.Errorf("body = %q, want %q", body, tt.wantBody)
+			}
+			if headers["x-test"] != tt.wantHeader {
+				t.Errorf("header = %q, want %q", headers["x-test"], tt.wantHeader)
+			}
+		})
+	}
+}
+
+func TestRenderTemplate(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		setup    func(req *http.Request)
+		template string
+		want     string
+		wantErr  string
+	}{
+		{
+			name:     "request data",
+			template: `{{ .Method }} {{ .Path }} {{ .Params.id }} {{ .Query.Get "page" }}`,
+			want:     "POST /users/42 42 2",
+		},
+		{
+			name: "headers and cookies",
+			setup: func(req *http.Request) {
+				req.Header.Set("X-Request-Id", "abc")
+				req.AddCookie(&http.Cookie{Name: "session", Value: "s1"})
+			},
+			template: `{{ .Headers.Get "X-Request-Id" }} {{ .Cookies.session }}`,
+			want:     "abc s1",
+		},
+		{
+			name:     "raw body",
+			body:     "plain text",
+			template: `{{ .Body }}`,
+			want:     "plain text",
+		},
+		{
+			name:     "JSON body",
+			body:     `{"user": {"id": 12345678901234567890, "name": "jane"}, "tags": ["a", "b"]}`,
+			template: `{{ .JSON.user.name }} {{ .JSON.user.id }} {{ index .JSON.tags 1 }}`,
+			want:     "jane 12345678901234567890 b",
+		},
+		{
+			name:     "jsonPath",
+			body:     `{"items": [{"id": 1}, {"id": 2.5}]}`,
+			template: `{{ jsonPath .JSON "$.items[1].id" }} {{ jsonPath .JSON "$.missing" }}`,
+			want:     "2.5 <no value>",
+		},
+		{
+			name:     "body that is not JSON",
+			body:     "not json",
+			template: `{{ if .JSON }}json{{ else }}text{{ end }}`,
+			want:     "text",
+		},
+		{
+			name:     "missing keys",
+			template: `[{{ .Params.missing }}]`,
+			want:     "[]",
+		},
+		{
+			name:     "toJSON",
+			body:     `{"user": {"name": "jane"}}`,
+			template: `{{ toJSON .JSON.user }} {{ toJSON .Params }}`,
+			want:     `{"name":"jane"} {"id":"42"}`,
+		},
+		{
+			name:     "base64",
+			template: `{{ b64enc "hello" }} {{ b64dec "aGVsbG8=" }}`,
+			want:     "aGVsbG8= hello",
+		},
+		{
+			name:     "invalid base64",
+			template: `{{ b64dec "!" }}`,
+			wantErr:  "illegal base64 data",
+		},
+		{
+			name:     "case",
+			template: `{{ upper "Jane" }} {{ lower "Jane" }}`,
+			want:     "JANE jane",
+		},
+		{
+			name:     "randInt with an empty range",
+			template: `{{ randInt 5 5 }}`,
+			wantErr:  "randInt: 5 must be greater than 5",
+		},
+		{
+			name:     "execution error",
+			template: `{{ index .Params.id 10 }}`,
+			wantErr:  "error calling index",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/users/42?page=2", strings.NewReader(tt.body))
+			if tt.setup != nil {
+				tt.setup(req)
+			}
+			method := MethodConfig{Template: true}
+			response := ResponseConfig{Body: tt.template}
+
+			body, _, err := method.render(response, req, map[string]string{"id": "42"})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("render error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("render: %v", err)
+			}
+			if body != tt.want {
+				t.Errorf("body = %q, want %q", body, tt.want)
+			}
+		})
+	}
+}
+
+func TestTemplateFuncs(t *testing.T) {
+	uuidPattern := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	for range 10 {
+		if id := newUUID(); !uuidPattern.MatchString(id) {
+			t.Errorf("newUUID() = %q, want a version 4 UUID", id)
+		}
+	}
+
+	for range 100 {
+		n, err := randInt(-2, 3)
+		if err != nil {
+			t.Fatalf("randInt: %v", err)
+		}
+		if n < -2 || n >= 3 {
+			t.Errorf("randInt(-2, 3) = %d, want a value in [-2, 3)", n)
+		}
+	}
+	if _, err := randInt(3, 2); err == nil {
+		t.Error("randInt(3, 2) error = nil, want an error")
+	}
+
+	if _, err := toJSON(make(chan int)); err == nil {
+		t.Error("toJSON(chan) error = nil, want an error")
+	}
+}
+
+func TestRenderTemplateHeaders(t *testing.T) {
+	method := MethodConfig{Template: true}
+	response := ResponseConfig{
+		Body:    "{{ .Params.id }}",
+		Headers: map[string]string{"Location": "/users/{{ .Params.id }}", "X-Static": "plain"},
+	}
+	req := httptest.NewRequest("POST", "/users", nil)
+
+	_, headers, err := method.render(response, req, map[string]string{"id": "42"})
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if headers["Location"] != "/users/42" || headers["X-Static"] != "plain" {
+		t.Errorf("headers = %v, want rendered Location and plain X-Static", headers)
+	}
+
+	response.Headers["X-Broken"] = "{{ .Params.id "
+	if _, _, err := method.render(response, req, nil); err == nil || !strings.Contains(err.Error(), "header X-Broken") {
+		t.Errorf("render error = %v, want an error naming header X-Broken", err)
+	}
+}
+
+func TestValidateTemplates(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  MethodConfig
+		wantErr string
+	}{
+		{
+			name:   "valid",
+			method: MethodConfig{Body: "{{ .Params.id }}", Responses: []ResponseConfig{{Body: "{{ uuid }}"}}},
+		},
+		{
+			name:    "invalid body",
+			method:  MethodConfig{Body: "{{ .Params.id "},
+			wantErr: "invalid body template",
+		},
+		{
+			name:    "invalid variant header",
+			method:  MethodConfig{Responses: []ResponseConfig{{Headers: map[string]string{"X-Id": "{{ unknown }}"}}}},
+			wantErr: "invalid template for header X-Id",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.method.validateTemplates()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validateTemplates() error = %v, want none", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validateTemplates() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTemplateCacheBounded(t *testing.T) {
+	for i := range templateCache.size + 10 {
+		if _, err := parseTemplate("{{ .Params.id }}" + strconv.Itoa(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if templateCache.Len() > templateCache.size {
+		t.Errorf("templateCache holds %d templates, want at most %d", templateCache.Len(), templateCache.size)
+	}
+}
@@ -0,0 +1,363 @@
+package static
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/antonjah/static/internal/certs"
+	"github.com/antonjah/static/internal/config"
+	"github.com/fsnotify/fsnotify"
+	"go.uber.org/zap"
+	"golang.org/x/exp/slices"
+)
+
+// Validity of the generated certificates
+const (
+	generatedCAValidity       = 365 * 24 * time.Hour
+	clientCertificateValidity = 24 * time.Hour
+)
+
+// certificates serves the TLS certificates and client CA pool read from files, and reloads
+// them when the files change, e.g. when a mounted Secret is rotated. Without files they
+// are generated by an ephemeral CA instead
+type certificates struct {
+	certFiles []string
+	keyFiles  []string
+	caFile    string
+	metrics   *metrics
+
+	// ca issued the certificate and verifies client certificates if it was generated
+	ca *certs.CA
+
+	mu           sync.RWMutex
+	certificates []tls.Certificate
+	clientCAs    *x509.CertPool
+}
+
+// newCertificates loads the certificates with the keys at the same positions, and the
+// optional client CA.
+func newCertificates(certFiles, keyFiles []string, caFile string, metrics *metrics) (*certificates, error) {
+	if len(certFiles) != len(keyFiles) {
+		return nil, fmt.Errorf("got %d TLS certificates but %d keys", len(certFiles), len(keyFiles))
+	}
+
+	c := &certificates{
+		certFiles: certFiles,
+		keyFiles:  keyFiles,
+		caFile:    caFile,
+		metrics:   metrics,
+	}
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// generateCertificates creates an ephemeral CA and a server certificate for hosts signed by
+// it. The CA also verifies client certificates, so the certificates it issues are accepted.
+func generateCertificates(hosts []string, metrics *metrics) (*certificates, error) {
+	ca, err := certs.NewCA("static-ca", generatedCAValidity)
+	if err != nil {
+		return nil, err
+	}
+	certificatePEM, keyPEM, err := ca.IssueServer(hosts[0], hosts, generatedCAValidity)
+	if err != nil {
+		return nil, err
+	}
+	certificate, err := tls.X509KeyPair(certificatePEM, keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load generated certificate: %w", err)
+	}
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca.Certificate)
+
+	metrics.tlsLoaded([]tls.Certificate{certificate})
+	return &certificates{
+		metrics:      metrics,
+		ca:           ca,
+		certificates: []tls.Certificate{certificate},
+		clientCAs:    clientCAs,
+	}, nil
+}
+
+// tlsHosts returns the hosts generated certificates are issued for: TLS_HOSTS followed by
+// localhost and the hostname of the machine.
+func tlsHosts(cfg config.Config) []string {
+	hosts := []string{}
+	add := func(host string) {
+		if host = strings.TrimSpace(host); host != "" && !slices.Contains(hosts, host) {
+			hosts = append(hosts, host)
+		}
+	}
+	for _, host := range cfg.TLSHosts {
+		add(host)
+	}
+	add("localhost")
+	add("127.0.0.1")
+	add("::1")
+	if hostname, err := os.Hostname(); err == nil {
+		add(hostname)
+	}
+	return hosts
+}
+
+// load reads the files, keeping the certificates in use if any of them is invalid.
+func (c *certificates) load() error {
+	loaded := make([]tls.Certificate, len(c.certFiles))
+	for i := range c.certFiles {
+		certificate, err := tls.LoadX509KeyPair(c.certFiles[i], c.keyFiles[i])
+		if err != nil {
+			return fmt.Errorf("failed to load TLS certificate %s: %w", c.certFiles[i], err)
+		}
+		loaded[i] = certificate
+	}
+
+	var clientCAs *x509.CertPool
+	if c.caFile != "" {
+		caCert, err := os.ReadFile(c.caFile)
+		if err != nil {
+			return fmt.Errorf("failed to read CA certificate: %w", err)
+		}
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(caCert) {
+			return fmt.Errorf("no certificates found in CA file %s", c.caFile)
+		}
+	}
+
+	c.mu.Lock()
+	c.certificates = loaded
+	c.clientCAs = clientCAs
+	c.mu.Unlock()
+
+	c.metrics.tlsLoaded(loaded)
+	return nil
+}
+
+// tlsConfig returns a TLS configuration serving the current certificates to every new
+// connection, verifying client certificates with clientAuth. The first certificate covering
+// the SNI name of the connection is served, or the first certificate if none does. Each
+// connection gets a clone of the configuration with the current client CA pool.
+func (c *certificates) tlsConfig(clientAuth tls.ClientAuthType) *tls.Config {
+	getCertificate := func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+		c.mu.RLock()
+		defer c.mu.RUnlock()
+		for i := range c.certificates {
+			if hello.SupportsCertificate(&c.certificates[i]) == nil {
+				return &c.certificates[i], nil
+			}
+		}
+		return &c.certificates[0], nil
+	}
+
+	config := &tls.Config{
+		GetCertificate: getCertificate,
+		ClientAuth:     clientAuth,
+		// listed up front, as connections are served clones of this configuration
+		// rather than of the one net/http completes with the protocols it supports
+		NextProtos: []string{"h2", "http/1.1"},
+	}
+	config.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		c.mu.RLock()
+		defer c.mu.RUnlock()
+		clone := config.Clone()
+		clone.GetConfigForClient = nil
+		clone.ClientCAs = c.clientCAs
+		return clone, nil
+	}
+	return config
+}
+
+// files returns the files the certificates are read from.
+func (c *certificates) files() map[string]bool {
+	files := map[string]bool{}
+	for i := range c.certFiles {
+		files[filepath.Clean(c.certFiles[i])] = true
+		files[filepath.Clean(c.keyFiles[i])] = true
+	}
+	if c.caFile != "" {
+		files[filepath.Clean(c.caFile)] = true
+	}
+	return files
+}
+
+// reload loads the certificates again, logging and counting the outcome.
+func (c *certificates) reload() {
+	if err := c.load(); err != nil {
+		zap.L().Error("failed to reload TLS certificates", zap.Error(err))
+		c.metrics.tlsLoadFailed()
+		return
+	}
+	zap.L().Info("TLS certificates reloaded",
+		zap.Strings("certificates", c.certFiles),
+		zap.String("ca", c.caFile))
+}
+
+// watch reloads the certificates whenever one of their files changes, including the
+// ..data link swapped when a mounted Secret is updated, and polls them as a fallback.
+// Generated certificates are not watched.
+func (c *certificates) watch(ctx context.Context) {
+	if c.ca != nil {
+		return
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		zap.L().Fatal("failed to create file watcher", zap.Error(err))
+	}
+	defer watcher.Close()
+
+	files := c.files()
+	for file := range files {
+		dir := filepath.Dir(file)
+		if err := watcher.Add(dir); err != nil {
+			zap.L().Fatal("failed to watch TLS certificate directory", zap.String("dir", dir), zap.Error(err))
+		}
+	}
+
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+
+	lastModTime := latestModTime(files)
+
+	zap.L().Info("watching TLS certificates", zap.Strings("certificates", c.certFiles))
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op&fsnotify.Chmod != 0 {
+				continue
+			}
+
+			if files[filepath.Clean(event.Name)] || filepath.Base(event.Name) == "..data" {
+				zap.L().Info("TLS certificate changed, reloading",
+					zap.String("file", event.Name),
+					zap.String("op", event.Op.String()))
+				// wait for both the certificate and the key to be written
+				time.Sleep(100 * time.Millisecond)
+				c.reload()
+				lastModTime = latestModTime(files)
+			}
+		case <-ticker.C:
+			if modTime := latestModTime(files); modTime.After(lastModTime) {
+				lastModTime = modTime
+				zap.L().Info("TLS certificate changed (poll), reloading")
+				c.reload()
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			zap.L().Error("watcher error", zap.Error(err))
+		}
+	}
+}
+
+// clientCertificateRequest is the body of a request for a client certificate
+type clientCertificateRequest struct {
+	CommonName          string   `json:"commonName"`
+	OrganizationalUnits []string `json:"organizationalUnits"`
+	// SANs are DNS names, IP addresses, email addresses or URIs
+	SANs     []string `json:"sans"`
+	ValidFor string   `json:"validFor"`
+}
+
+// clientCertificateResponse holds the PEM encoded client certificate, its key and the CA
+type clientCertificateResponse struct {
+	Certificate string `json:"certificate"`
+	Key         string `json:"key"`
+	CA          string `json:"ca"`
+}
+
+// generatedCA returns the CA the TLS certificates were generated by, or nil.
+func (s *Server) generatedCA() *certs.CA {
+	if s.certificates == nil {
+		return nil
+	}
+	return s.certificates.ca
+}
+
+// handleCA returns the PEM encoded CA certificate of generated TLS certificates on GET.
+func (s *Server) handleCA(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	ca := s.generatedCA()
+	if ca == nil {
+		http.Error(w, "TLS certificates are not generated", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-pem-file")
+	w.Write(ca.CertificatePEM)
+}
+
+// handleClientCertificate issues a client certificate signed by the generated CA on POST.
+func (s *Server) handleClientCertificate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	ca := s.generatedCA()
+	if ca == nil {
+		http.Error(w, "TLS certificates are not generated", http.StatusNotFound)
+		return
+	}
+
+	// an empty body requests a certificate with the defaults
+	var request clientCertificateRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, fmt.Sprintf("invalid client certificate request: %v", err), http.StatusBadRequest)
+		return
+	}
+	if request.CommonName == "" {
+		request.CommonName = "client"
+	}
+	validFor := clientCertificateValidity
+	if request.ValidFor != "" {
+		var err error
+		if validFor, err = time.ParseDuration(request.ValidFor); err != nil || validFor <= 0 {
+			http.Error(w, fmt.Sprintf("invalid validFor %q", request.ValidFor), http.StatusBadRequest)
+			return
+		}
+	}
+	// certificates do not outlive the CA they are issued by
+	validFor = min(validFor, time.Until(ca.Certificate.NotAfter))
+
+	certificate, key, err := ca.IssueClient(request.CommonName, request.OrganizationalUnits, request.SANs, validFor)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	zap.L().Info("client certificate issued",
+		zap.String("commonName", request.CommonName),
+		zap.Strings("organizationalUnits", request.OrganizationalUnits),
+		zap.Strings("sans", request.SANs),
+		zap.Duration("validFor", validFor))
+
+	writeJSON(w, http.StatusCreated, clientCertificateResponse{
+		Certificate: string(certificate),
+		Key:         string(key),
+		CA:          string(ca.CertificatePEM),
+	})
+}
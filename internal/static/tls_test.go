@@ -0,0 +1,293 @@
+package static
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/antonjah/static/internal/certs"
+	"golang.org/x/exp/slices"
+)
+
+// newTestCA returns a CA for the tests and a pool trusting it.
+func newTestCA(t *testing.T, commonName string) (*certs.CA, *x509.CertPool) {
+	t.Helper()
+	ca, err := certs.NewCA(commonName, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.Certificate)
+	return ca, pool
+}
+
+// writeServerCertificate writes a server certificate for hosts issued by ca to dir and
+// returns the paths of the certificate and key.
+func writeServerCertificate(t *testing.T, dir string, ca *certs.CA, name string, hosts []string) (string, string) {
+	t.Helper()
+	certificatePEM, keyPEM, err := ca.IssueServer(hosts[0], hosts, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile, keyFile := filepath.Join(dir, name+".crt"), filepath.Join(dir, name+".key")
+	if err := os.WriteFile(certFile, certificatePEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, keyPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+// issueClientCertificate returns a client certificate issued by ca.
+func issueClientCertificate(t *testing.T, ca *certs.CA, commonName string) tls.Certificate {
+	t.Helper()
+	certificatePEM, keyPEM, err := ca.IssueClient(commonName, nil, nil, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certificate, err := tls.X509KeyPair(certificatePEM, keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return certificate
+}
+
+// serveTLS serves the protocol of each request with config and returns the address.
+func serveTLS(t *testing.T, config *tls.Config) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &http.Server{
+		TLSConfig: config,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.WriteString(w, r.Proto)
+		}),
+	}
+	go func() { _ = server.ServeTLS(listener, "", "") }()
+	t.Cleanup(func() { _ = server.Close() })
+	return listener.Addr().String()
+}
+
+// get requests https://host/ from addr and returns the response body.
+func get(t *testing.T, addr, host string, config *tls.Config, http2 bool) (string, error) {
+	t.Helper()
+	transport := &http.Transport{
+		TLSClientConfig:   config,
+		ForceAttemptHTTP2: http2,
+		DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, network, addr)
+		},
+	}
+	if !http2 {
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+	defer transport.CloseIdleConnections()
+
+	resp, err := (&http.Client{Transport: transport}).Get("https://" + host + "/")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	return string(body), err
+}
+
+func TestTLSConfigProtocols(t *testing.T) {
+	dir := t.TempDir()
+	ca, roots := newTestCA(t, "server-ca")
+	certFile, keyFile := writeServerCertificate(t, dir, ca, "server", []string{"localhost"})
+	c, err := newCertificates([]string{certFile}, []string{keyFile}, "", newMetrics())
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := serveTLS(t, c.tlsConfig(tls.NoClientCert))
+
+	tests := []struct {
+		name  string
+		http2 bool
+		want  string
+	}{
+		{name: "HTTP/2", http2: true, want: "HTTP/2.0"},
+		{name: "HTTP/1.1", want: "HTTP/1.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := get(t, addr, "localhost", &tls.Config{RootCAs: roots}, tt.http2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("protocol = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTLSConfigClientCAReload(t *testing.T) {
+	dir := t.TempDir()
+	ca, roots := newTestCA(t, "server-ca")
+	certFile, keyFile := writeServerCertificate(t, dir, ca, "server", []string{"localhost"})
+	oldCA, _ := newTestCA(t, "old-client-ca")
+	newCA, _ := newTestCA(t, "new-client-ca")
+
+	caFile := filepath.Join(dir, "ca.crt")
+	if err := os.WriteFile(caFile, oldCA.CertificatePEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	c, err := newCertificates([]string{certFile}, []string{keyFile}, caFile, newMetrics())
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := serveTLS(t, c.tlsConfig(tls.RequireAndVerifyClientCert))
+
+	request := func(clientCA *certs.CA) error {
+		config := &tls.Config{RootCAs: roots, Certificates: []tls.Certificate{issueClientCertificate(t, clientCA, "client")}}
+		got, err := get(t, addr, "localhost", config, true)
+		if err == nil && got != "HTTP/2.0" {
+			t.Errorf("protocol = %s, want HTTP/2.0", got)
+		}
+		return err
+	}
+
+	if err := request(oldCA); err != nil {
+		t.Fatalf("client certificate of the client CA rejected: %v", err)
+	}
+
+	if err := os.WriteFile(caFile, newCA.CertificatePEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.load(); err != nil {
+		t.Fatal(err)
+	}
+	if err := request(newCA); err != nil {
+		t.Fatalf("client certificate of the reloaded client CA rejected: %v", err)
+	}
+	if err := request(oldCA); err == nil {
+		t.Error("client certificate of the replaced client CA accepted")
+	}
+}
+
+func TestHandleClientCertificate(t *testing.T) {
+	ca, _ := newTestCA(t, "client-ca")
+	s := &Server{certificates: &certificates{ca: ca}}
+
+	tests := []struct {
+		name         string
+		method       string
+		body         string
+		wantStatus   int
+		wantNotAfter time.Time
+	}{
+		{name: "capped at the CA validity", method: "POST", body: `{"validFor": "48h"}`, wantStatus: 201, wantNotAfter: ca.Certificate.NotAfter},
+		{name: "default capped at the CA validity", method: "POST", wantStatus: 201, wantNotAfter: ca.Certificate.NotAfter},
+		{name: "within the CA validity", method: "POST", body: `{"validFor": "10m"}`, wantStatus: 201, wantNotAfter: time.Now().Add(10 * time.Minute)},
+		{name: "invalid validFor", method: "POST", body: `{"validFor": "-1h"}`, wantStatus: 400},
+		{name: "GET", method: "GET", wantStatus: 405},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			s.handleClientCertificate(w, httptest.NewRequest(tt.method, "/_static/tls/client", strings.NewReader(tt.body)))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantStatus != 201 {
+				return
+			}
+
+			var response clientCertificateResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatal(err)
+			}
+			block, _ := pem.Decode([]byte(response.Certificate))
+			if block == nil {
+				t.Fatal("no certificate in the response")
+			}
+			certificate, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d := certificate.NotAfter.Sub(tt.wantNotAfter); d > time.Second || d < -time.Minute {
+				t.Errorf("NotAfter = %s, want %s", certificate.NotAfter, tt.wantNotAfter)
+			}
+		})
+	}
+}
+
+func TestClientIssuerNotAdmin(t *testing.T) {
+	ca, _ := newTestCA(t, "client-ca")
+	s := &Server{certificates: &certificates{ca: ca}}
+	mux := http.NewServeMux()
+	s.registerAdmin(mux)
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("POST", "/_static/tls/client", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestTLSConfigSNI(t *testing.T) {
+	dir := t.TempDir()
+	ca, _ := newTestCA(t, "server-ca")
+	var certFiles, keyFiles []string
+	for name, hosts := range map[string][]string{
+		"0-default":  {"default.example"},
+		"1-payments": {"payments.example"},
+		"2-auth":     {"auth.example", "*.auth.example"},
+	} {
+		certFile, keyFile := writeServerCertificate(t, dir, ca, name, hosts)
+		certFiles, keyFiles = append(certFiles, certFile), append(keyFiles, keyFile)
+	}
+	slices.Sort(certFiles)
+	slices.Sort(keyFiles)
+	c, err := newCertificates(certFiles, keyFiles, "", newMetrics())
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := c.tlsConfig(tls.NoClientCert)
+
+	tests := []struct {
+		serverName string
+		want       string
+	}{
+		{serverName: "payments.example", want: "payments.example"},
+		{serverName: "auth.example", want: "auth.example"},
+		{serverName: "api.auth.example", want: "auth.example"},
+		{serverName: "default.example", want: "default.example"},
+		{serverName: "other.example", want: "default.example"},
+		{serverName: "", want: "default.example"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.serverName, func(t *testing.T) {
+			certificate, err := config.GetCertificate(&tls.ClientHelloInfo{
+				ServerName:        tt.serverName,
+				SignatureSchemes:  []tls.SignatureScheme{tls.ECDSAWithP256AndSHA256},
+				SupportedVersions: []uint16{tls.VersionTLS13},
+				CipherSuites:      []uint16{tls.TLS_AES_128_GCM_SHA256},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := certificate.Leaf.Subject.CommonName; got != tt.want {
+				t.Errorf("served certificate of %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
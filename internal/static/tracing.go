@@ -0,0 +1,135 @@
+package static
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const tracerName = "github.com/antonjah/static"
+
+// Span attributes of the matched StaticAPI
+const (
+	attributeStaticAPIName   = attribute.Key("static.api.name")
+	attributeStaticAPIPath   = attribute.Key("static.api.path")
+	attributeStaticAPISource = attribute.Key("static.api.source")
+)
+
+// tracing continues the traces of incoming requests with a span per request,
+// exported over OTLP/HTTP when OTEL_EXPORTER_OTLP_ENDPOINT is set
+type tracing struct {
+	provider   *sdktrace.TracerProvider
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+
+	// responseHeaders writes the trace context of the request span to the response,
+	// continuing the trace of the request or starting a new one
+	responseHeaders bool
+}
+
+// newTracing sets up tracing if an OTLP endpoint or trace response headers are configured,
+// and returns nil otherwise. The exporter reads the standard OTEL_EXPORTER_OTLP_* variables
+// for its endpoint, headers and timeout, and the SDK OTEL_TRACES_SAMPLER for sampling.
+func newTracing(ctx context.Context, endpoint string, responseHeaders bool) (*tracing, error) {
+	if endpoint == "" && !responseHeaders {
+		return nil, nil
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName("static")),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tracing resource: %w", err)
+	}
+	options := []sdktrace.TracerProviderOption{sdktrace.WithResource(res)}
+
+	// without an endpoint spans are only created for their trace context
+	if endpoint != "" {
+		exporter, err := otlptracehttp.New(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+		}
+		options = append(options, sdktrace.WithBatcher(exporter))
+	}
+
+	provider := sdktrace.NewTracerProvider(options...)
+	propagator := propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+
+	// the upstream proxies propagate the trace context through the global propagator
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagator)
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		zap.L().Error("tracing error", zap.Error(err))
+	}))
+
+	return &tracing{
+		provider:        provider,
+		tracer:          provider.Tracer(tracerName),
+		propagator:      propagator,
+		responseHeaders: responseHeaders,
+	}, nil
+}
+
+// handler serves requests with next in a server span continuing the trace context and
+// baggage of the request.
+func (t *tracing) handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := t.propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := t.tracer.Start(ctx, r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.URLPath(r.URL.Path),
+				semconv.UserAgentOriginal(r.UserAgent()),
+				semconv.ClientAddress(r.RemoteAddr),
+			))
+		defer span.End()
+
+		if t.responseHeaders {
+			t.propagator.Inject(ctx, propagation.HeaderCarrier(w.Header()))
+		}
+
+		recorder := newStatusRecorder(w)
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+
+		span.SetAttributes(semconv.HTTPResponseStatusCode(recorder.status))
+		if recorder.status >= 500 {
+			span.SetStatus(codes.Error, http.StatusText(recorder.status))
+		}
+	})
+}
+
+// shutdown exports the remaining spans.
+func (t *tracing) shutdown(ctx context.Context) error {
+	return t.provider.Shutdown(ctx)
+}
+
+// traceStaticAPI names the span of requests served by staticAPI after its path and
+// annotates it with the StaticAPI.
+func traceStaticAPI(staticAPI *StaticAPI, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		span := trace.SpanFromContext(r.Context())
+		if span.IsRecording() {
+			span.SetName(r.Method + " " + staticAPI.Path)
+			span.SetAttributes(
+				semconv.HTTPRoute(staticAPI.Path),
+				attributeStaticAPIName.String(staticAPI.Name),
+				attributeStaticAPIPath.String(staticAPI.Path),
+				attributeStaticAPISource.String(staticAPI.source),
+			)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,166 @@
+package static
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// newTestTracing returns tracing recording its spans, and sets its propagator globally for
+// the upstream proxies for the duration of the test.
+func newTestTracing(t *testing.T, responseHeaders bool) (*tracing, *tracetest.SpanRecorder) {
+	t.Helper()
+	spans := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))
+	propagator := propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+
+	global := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagator)
+	t.Cleanup(func() { otel.SetTextMapPropagator(global) })
+
+	return &tracing{
+		provider:        provider,
+		tracer:          provider.Tracer(tracerName),
+		propagator:      propagator,
+		responseHeaders: responseHeaders,
+	}, spans
+}
+
+// traceparent splits a traceparent header into its trace and span ID.
+func traceparent(header string) (string, string) {
+	parts := strings.Split(header, "-")
+	if len(parts) != 4 {
+		return "", ""
+	}
+	return parts[1], parts[2]
+}
+
+func TestTracingPropagation(t *testing.T) {
+	const (
+		traceID  = "4bf92f3577b34da6a3ce929d0e0e4736"
+		parentID = "00f067aa0ba902b7"
+	)
+	incoming := map[string]string{
+		"traceparent": "00-" + traceID + "-" + parentID + "-01",
+		"baggage":     "tenant=acme",
+	}
+
+	// the upstream returns the trace context it receives
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Upstream-Traceparent", r.Header.Get("traceparent"))
+		w.Header().Set("X-Upstream-Baggage", r.Header.Get("baggage"))
+	}))
+	defer upstream.Close()
+	proxy, err := newUpstreams(upstream.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	staticAPI := &StaticAPI{Name: "users", Path: "/users/{id}", source: sourceFile}
+	ok := traceStaticAPI(staticAPI, http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	failing := traceStaticAPI(staticAPI, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+
+	tests := []struct {
+		name            string
+		handler         http.Handler
+		headers         map[string]string
+		responseHeaders bool
+		wantName        string
+		wantContinued   bool
+		wantError       bool
+		wantUpstream    bool
+	}{
+		{name: "continues the trace of the request", handler: ok, headers: incoming, wantName: "GET /users/{id}", wantContinued: true},
+		{name: "starts a trace", handler: ok, wantName: "GET /users/{id}"},
+		{name: "returns the trace context", handler: ok, headers: incoming, responseHeaders: true, wantName: "GET /users/{id}", wantContinued: true},
+		{name: "returns a new trace context", handler: ok, responseHeaders: true, wantName: "GET /users/{id}"},
+		{name: "marks server errors", handler: failing, headers: incoming, wantName: "GET /users/{id}", wantContinued: true, wantError: true},
+		{name: "propagates to upstreams", handler: proxy, headers: incoming, wantName: "GET", wantContinued: true, wantUpstream: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracing, spans := newTestTracing(t, tt.responseHeaders)
+			req := httptest.NewRequest("GET", "/users/42", nil)
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+			w := httptest.NewRecorder()
+			tracing.handler(tt.handler).ServeHTTP(w, req)
+
+			ended := spans.Ended()
+			if len(ended) != 1 {
+				t.Fatalf("spans = %d, want 1", len(ended))
+			}
+			span := ended[0]
+			if span.Name() != tt.wantName {
+				t.Errorf("span name = %q, want %q", span.Name(), tt.wantName)
+			}
+			if span.SpanKind() != trace.SpanKindServer {
+				t.Errorf("span kind = %s, want server", span.SpanKind())
+			}
+			continued := span.SpanContext().TraceID().String() == traceID && span.Parent().SpanID().String() == parentID
+			if continued != tt.wantContinued {
+				t.Errorf("trace %s, parent %s continued = %v, want %v", span.SpanContext().TraceID(), span.Parent().SpanID(), continued, tt.wantContinued)
+			}
+			if (span.Status().Code == codes.Error) != tt.wantError {
+				t.Errorf("span status = %v, want error %v", span.Status(), tt.wantError)
+			}
+			if !tt.wantUpstream && !hasAttribute(span.Attributes(), attributeStaticAPIName.String("users")) {
+				t.Errorf("span attributes %v lack the StaticAPI name", span.Attributes())
+			}
+
+			// the trace context is returned in the response headers only if configured
+			responseTrace, responseSpan := traceparent(w.Header().Get("traceparent"))
+			if tt.responseHeaders {
+				if responseTrace != span.SpanContext().TraceID().String() || responseSpan != span.SpanContext().SpanID().String() {
+					t.Errorf("response traceparent = %q, want the request span", w.Header().Get("traceparent"))
+				}
+				if tt.wantContinued && w.Header().Get("baggage") != "tenant=acme" {
+					t.Errorf("response baggage = %q, want tenant=acme", w.Header().Get("baggage"))
+				}
+			} else if w.Header().Get("traceparent") != "" {
+				t.Errorf("response traceparent = %q, want none", w.Header().Get("traceparent"))
+			}
+
+			// upstreams continue the trace from the request span
+			if tt.wantUpstream {
+				upstreamTrace, upstreamParent := traceparent(w.Header().Get("X-Upstream-Traceparent"))
+				if upstreamTrace != traceID || upstreamParent != span.SpanContext().SpanID().String() {
+					t.Errorf("upstream traceparent = %q, want a child of the request span", w.Header().Get("X-Upstream-Traceparent"))
+				}
+				if w.Header().Get("X-Upstream-Baggage") != "tenant=acme" {
+					t.Errorf("upstream baggage = %q, want tenant=acme", w.Header().Get("X-Upstream-Baggage"))
+				}
+			}
+		})
+	}
+}
+
+// hasAttribute reports whether attributes contain want.
+func hasAttribute(attributes []attribute.KeyValue, want attribute.KeyValue) bool {
+	for _, a := range attributes {
+		if a == want {
+			return true
+		}
+	}
+	return false
+}
+
+func TestNewTracingDisabled(t *testing.T) {
+	tracing, err := newTracing(t.Context(), "", false)
+	if err != nil || tracing != nil {
+		t.Errorf("newTracing = %v, %v, want nil without endpoint and response headers", tracing, err)
+	}
+}
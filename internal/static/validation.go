@@ -0,0 +1,119 @@
+package static
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/antonjah/static/internal/openapi/spec"
+	"go.uber.org/zap"
+)
+
+// ValidationConfig validates requests against JSON schemas before they are answered.
+// Params, Query and Headers are object schemas with a property per parameter, and Body
+// is the schema of a JSON request body. Requests violating them are answered with
+// StatusCode (default: 400) and a JSON list of the violations.
+type ValidationConfig struct {
+	Params     *spec.Schema `yaml:"params,omitempty"`
+	Query      *spec.Schema `yaml:"query,omitempty"`
+	Headers    *spec.Schema `yaml:"headers,omitempty"`
+	Body       *spec.Schema `yaml:"body,omitempty"`
+	StatusCode int          `yaml:"status-code,omitempty"`
+
+	// invalid is the error of decoding the schemas of a StaticAPI CRD
+	invalid error
+}
+
+// validation holds the server wide request validation settings shared with the StaticAPIs
+type validation struct {
+	openapi    *spec.Document
+	statusCode int
+}
+
+// ValidationError is the response to a request failing validation
+type ValidationError struct {
+	Message    string           `json:"message"`
+	Violations []spec.Violation `json:"violations"`
+}
+
+// schemaDocument is an empty OpenAPI document to validate the standalone schemas of methods with
+var schemaDocument = &spec.Document{}
+
+// Validate checks the schemas and the status code returned for invalid requests.
+// Method schemas are standalone, so a $ref in them cannot be resolved and is rejected.
+func (v *ValidationConfig) Validate() error {
+	if v.invalid != nil {
+		return v.invalid
+	}
+	for _, schema := range []struct {
+		name   string
+		schema *spec.Schema
+	}{{"params", v.Params}, {"query", v.Query}, {"headers", v.Headers}, {"body", v.Body}} {
+		if err := schemaDocument.CheckSchema(schema.schema); err != nil {
+			return fmt.Errorf("invalid validation %s schema: %w", schema.name, err)
+		}
+	}
+	if v.StatusCode != 0 && (v.StatusCode < 100 || v.StatusCode > 599) {
+		return fmt.Errorf("invalid validation status-code: %d", v.StatusCode)
+	}
+	return nil
+}
+
+// validateRequest validates the request against the OpenAPI document of the server and
+// the schemas of the method. It writes the violations and returns false if it is invalid.
+func (e *StaticAPI) validateRequest(w http.ResponseWriter, method *MethodConfig, req *http.Request) bool {
+	if (e.validation == nil || e.validation.openapi == nil) && method.Validation == nil {
+		return true
+	}
+
+	body := readBody(req)
+	var violations []spec.Violation
+
+	status := http.StatusBadRequest
+	if e.validation != nil {
+		if e.validation.statusCode != 0 {
+			status = e.validation.statusCode
+		}
+		if e.validation.openapi != nil {
+			names := pathParamNames(e.Path)
+			values := make([]string, len(names))
+			for i, name := range names {
+				values[i] = req.PathValue(name)
+			}
+			violations = append(violations, e.validation.openapi.ValidateRequest(req, e.Path, values, body)...)
+		}
+	}
+
+	if v := method.Validation; v != nil {
+		if v.StatusCode != 0 {
+			status = v.StatusCode
+		}
+		violations = append(violations, schemaDocument.ValidateParameters(spec.InPath, v.Params, func(name string) []string {
+			if value := req.PathValue(name); value != "" {
+				return []string{value}
+			}
+			return nil
+		})...)
+		violations = append(violations, schemaDocument.ValidateParameters(spec.InQuery, v.Query, func(name string) []string {
+			return req.URL.Query()[name]
+		})...)
+		violations = append(violations, schemaDocument.ValidateParameters(spec.InHeader, v.Headers, req.Header.Values)...)
+		if v.Body != nil {
+			if len(body) == 0 {
+				violations = append(violations, spec.Violation{In: spec.InBody, Message: "is required"})
+			} else {
+				violations = append(violations, schemaDocument.ValidateBody(body, v.Body)...)
+			}
+		}
+	}
+
+	if len(violations) == 0 {
+		return true
+	}
+
+	zap.L().Debug("request validation failed",
+		zap.String("path", e.Path),
+		zap.String("method", req.Method),
+		zap.Any("violations", violations))
+	writeJSON(w, status, ValidationError{Message: "request validation failed", Violations: violations})
+	return false
+}
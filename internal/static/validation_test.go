@@ -0,0 +1,177 @@
+package static
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/antonjah/static/internal/config"
+	"github.com/antonjah/static/internal/openapi/spec"
+)
+
+func TestValidateRequest(t *testing.T) {
+	s := newTestServer(t, config.Config{}, `staticapis:
+- path: /users/{id}
+  methods:
+  - method: PUT
+    status-code: 204
+    validation:
+      params:
+        type: object
+        properties:
+          id: {type: integer}
+      query:
+        type: object
+        properties:
+          dryRun: {type: boolean}
+      headers:
+        type: object
+        required: [X-Api-Key]
+      body:
+        type: object
+        required: [name]
+  - method: DELETE
+    status-code: 204
+    validation:
+      status-code: 422
+      query:
+        type: object
+        required: [force]
+  - method: GET
+    status-code: 200
+`)
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		apiKey     string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "valid", method: http.MethodPut, target: "/users/1?dryRun=true", apiKey: "secret", body: `{"name": "a"}`, wantStatus: http.StatusNoContent},
+		{name: "path parameter", method: http.MethodPut, target: "/users/me", apiKey: "secret", body: `{"name": "a"}`, wantStatus: http.StatusBadRequest, wantBody: `{"in":"path","name":"id","message":"must be an integer"}`},
+		{name: "query parameter", method: http.MethodPut, target: "/users/1?dryRun=maybe", apiKey: "secret", body: `{"name": "a"}`, wantStatus: http.StatusBadRequest, wantBody: `{"in":"query","name":"dryRun","message":"must be a boolean"}`},
+		{name: "missing header", method: http.MethodPut, target: "/users/1", body: `{"name": "a"}`, wantStatus: http.StatusBadRequest, wantBody: `{"in":"header","name":"X-Api-Key","message":"is required"}`},
+		{name: "missing body", method: http.MethodPut, target: "/users/1", apiKey: "secret", wantStatus: http.StatusBadRequest, wantBody: `{"in":"body","message":"is required"}`},
+		{name: "invalid body", method: http.MethodPut, target: "/users/1", apiKey: "secret", body: `{}`, wantStatus: http.StatusBadRequest, wantBody: `{"in":"body","name":"$.name","message":"is required"}`},
+		{name: "custom status", method: http.MethodDelete, target: "/users/1", wantStatus: http.StatusUnprocessableEntity, wantBody: `"message":"request validation failed"`},
+		{name: "valid custom status", method: http.MethodDelete, target: "/users/1?force=true", wantStatus: http.StatusNoContent},
+		{name: "no validation", method: http.MethodGet, target: "/users/me", wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			if tt.apiKey != "" {
+				req.Header.Set("X-Api-Key", tt.apiKey)
+			}
+
+			w := httptest.NewRecorder()
+			s.ServeHTTP(w, req)
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("body = %s, want it to contain %s", w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestValidateRequestOpenAPI(t *testing.T) {
+	document := filepath.Join(t.TempDir(), "openapi.yaml")
+	if err := os.WriteFile(document, []byte(`openapi: 3.0.3
+paths:
+  /pets/{petId}:
+    get:
+      parameters:
+      - {name: petId, in: path, schema: {type: integer}}
+      - {name: limit, in: query, schema: {type: integer, maximum: 100}}
+`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	s := newTestServer(t, config.Config{ValidationOpenAPI: document, ValidationStatus: http.StatusUnprocessableEntity}, `staticapis:
+- path: /pets/{id}
+  methods:
+  - method: GET
+    status-code: 200
+  - method: DELETE
+    status-code: 204
+    validation:
+      status-code: 409
+      query:
+        type: object
+        required: [reason]
+- path: /owners/{id}
+  methods:
+  - method: GET
+    status-code: 200
+`)
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "valid", method: http.MethodGet, target: "/pets/1?limit=10", wantStatus: http.StatusOK},
+		{name: "path parameter by document name", method: http.MethodGet, target: "/pets/rex", wantStatus: http.StatusUnprocessableEntity, wantBody: `{"in":"path","name":"petId","message":"must be an integer"}`},
+		{name: "query parameter", method: http.MethodGet, target: "/pets/1?limit=1000", wantStatus: http.StatusUnprocessableEntity, wantBody: `{"in":"query","name":"limit","message":"must be at most 100"}`},
+		{name: "method status overrides server status", method: http.MethodDelete, target: "/pets/1", wantStatus: http.StatusConflict, wantBody: `{"in":"query","name":"reason","message":"is required"}`},
+		{name: "undocumented path", method: http.MethodGet, target: "/owners/x", wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := serve(s, tt.method, tt.target, "")
+			if status != tt.wantStatus {
+				t.Errorf("status = %d, want %d", status, tt.wantStatus)
+			}
+			if !strings.Contains(body, tt.wantBody) {
+				t.Errorf("body = %s, want it to contain %s", body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestValidationConfigValidate(t *testing.T) {
+	tests := []struct {
+		name       string
+		validation ValidationConfig
+		wantErr    string
+	}{
+		{name: "empty"},
+		{name: "status code", validation: ValidationConfig{StatusCode: 422}},
+		{name: "invalid status code", validation: ValidationConfig{StatusCode: 600}, wantErr: "invalid validation status-code: 600"},
+		{
+			name:       "pattern",
+			validation: ValidationConfig{Query: &spec.Schema{Properties: map[string]*spec.Schema{"q": {Pattern: "^[a-z]+$"}}}},
+		},
+		{
+			name:       "invalid pattern",
+			validation: ValidationConfig{Query: &spec.Schema{Properties: map[string]*spec.Schema{"q": {Pattern: "[a-z"}}}},
+			wantErr:    `invalid validation query schema: $.q: invalid pattern "[a-z"`,
+		},
+		{
+			name:       "ref",
+			validation: ValidationConfig{Body: &spec.Schema{AllOf: []*spec.Schema{{Ref: "#/components/schemas/User"}}}},
+			wantErr:    `invalid validation body schema: $.allOf[0]: $ref "#/components/schemas/User" cannot be resolved`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.validation.Validate()
+			if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"sync"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// CAInjector keeps the CA of the bootstrapped webhook certificate in the caBundle of the
+// ValidatingWebhookConfiguration, also after it is reapplied, e.g. by a Helm upgrade.
+type CAInjector struct {
+	client.Client
+	ConfigName string
+	CABundle   []byte
+
+	mu sync.Mutex
+}
+
+// SetCABundle replaces the injected CA, e.g. after CertRenewer replaced it, and injects it
+// right away.
+func (r *CAInjector) SetCABundle(ctx context.Context, caBundle []byte) error {
+	r.mu.Lock()
+	changed := !bytes.Equal(r.CABundle, caBundle)
+	r.CABundle = caBundle
+	r.mu.Unlock()
+
+	if !changed {
+		return nil
+	}
+	_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: r.ConfigName}})
+	return err
+}
+
+func (r *CAInjector) caBundle() []byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.CABundle
+}
+
+func (r *CAInjector) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	var config admissionregistrationv1.ValidatingWebhookConfiguration
+	if err := r.Get(ctx, req.NamespacedName, &config); err != nil {
+		if errors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	caBundle := r.caBundle()
+	patch := client.MergeFrom(config.DeepCopy())
+	changed := false
+	for i := range config.Webhooks {
+		if !bytes.Equal(config.Webhooks[i].ClientConfig.CABundle, caBundle) {
+			config.Webhooks[i].ClientConfig.CABundle = caBundle
+			changed = true
+		}
+	}
+	if !changed {
+		return ctrl.Result{}, nil
+	}
+
+	if err := r.Patch(ctx, &config, patch); err != nil {
+		logger.Error(err, "failed to inject CA into ValidatingWebhookConfiguration")
+		return ctrl.Result{}, err
+	}
+
+	logger.Info("CA injected into ValidatingWebhookConfiguration", "name", config.Name)
+	return ctrl.Result{}, nil
+}
+
+func (r *CAInjector) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("cainjector").
+		For(&admissionregistrationv1.ValidatingWebhookConfiguration{},
+			builder.WithPredicates(predicate.NewPredicateFuncs(func(obj client.Object) bool {
+				return obj.GetName() == r.ConfigName
+			}))).
+		Complete(r)
+}
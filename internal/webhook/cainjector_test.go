@@ -0,0 +1,73 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestCAInjector(t *testing.T) {
+	scheme := newScheme(t)
+	if err := admissionregistrationv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	caBundle := []byte("ca")
+	tests := []struct {
+		name     string
+		webhooks []admissionregistrationv1.ValidatingWebhook
+	}{
+		{name: "without CA", webhooks: []admissionregistrationv1.ValidatingWebhook{
+			{Name: "vstatic.static.io"},
+			{Name: "vstaticapi.static.io"},
+		}},
+		{name: "with outdated CA", webhooks: []admissionregistrationv1.ValidatingWebhook{
+			{Name: "vstatic.static.io", ClientConfig: admissionregistrationv1.WebhookClientConfig{CABundle: []byte("old")}},
+			{Name: "vstaticapi.static.io", ClientConfig: admissionregistrationv1.WebhookClientConfig{CABundle: caBundle}},
+		}},
+		{name: "with CA", webhooks: []admissionregistrationv1.ValidatingWebhook{
+			{Name: "vstatic.static.io", ClientConfig: admissionregistrationv1.WebhookClientConfig{CABundle: caBundle}},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &admissionregistrationv1.ValidatingWebhookConfiguration{
+				ObjectMeta: metav1.ObjectMeta{Name: "static-operator"},
+				Webhooks:   tt.webhooks,
+			}
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(config).Build()
+			r := &CAInjector{Client: c, ConfigName: "static-operator", CABundle: caBundle}
+
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "static-operator"}}
+			if _, err := r.Reconcile(context.Background(), req); err != nil {
+				t.Fatalf("Reconcile: %v", err)
+			}
+
+			var injected admissionregistrationv1.ValidatingWebhookConfiguration
+			if err := c.Get(context.Background(), client.ObjectKey{Name: "static-operator"}, &injected); err != nil {
+				t.Fatal(err)
+			}
+			for _, webhook := range injected.Webhooks {
+				if !bytes.Equal(webhook.ClientConfig.CABundle, caBundle) {
+					t.Errorf("webhook %s has CA %q, want %q", webhook.Name, webhook.ClientConfig.CABundle, caBundle)
+				}
+			}
+		})
+	}
+
+	t.Run("missing configuration", func(t *testing.T) {
+		r := &CAInjector{Client: fake.NewClientBuilder().WithScheme(scheme).Build(), ConfigName: "static-operator", CABundle: caBundle}
+		req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "static-operator"}}
+		if _, err := r.Reconcile(context.Background(), req); err != nil {
+			t.Fatalf("Reconcile: %v", err)
+		}
+	})
+}
@@ -0,0 +1,226 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	"github.com/antonjah/static/internal/certs"
+)
+
+// Validity of the bootstrapped certificates
+const (
+	caValidity          = 10 * 365 * 24 * time.Hour
+	certificateValidity = 365 * 24 * time.Hour
+	certificateRenewal  = 30 * 24 * time.Hour
+
+	// how often CertRenewer checks whether the certificate is due for renewal
+	certificateCheckInterval = time.Hour
+)
+
+// CertOptions configure the bootstrapping of the webhook serving certificate
+type CertOptions struct {
+	// CertDir is the directory the webhook server reads tls.crt and tls.key from
+	CertDir string
+	// ServiceName and Namespace of the Service in front of the webhook server
+	ServiceName string
+	Namespace   string
+	// SecretName is the Secret in Namespace the CA and certificate are kept in,
+	// so all replicas of the operator serve the same certificate
+	SecretName string
+}
+
+// EnsureCertificate writes the webhook serving certificate to CertDir and returns the CA
+// to inject into the ValidatingWebhookConfiguration. A certificate already in CertDir, e.g.
+// mounted from cert-manager or written by envtest, is used as is and no CA is returned.
+// Otherwise a self-signed CA and a certificate for the Service are created in the Secret,
+// or renewed when about to expire. c must read from the API server directly, since the
+// manager cache is not started yet.
+func EnsureCertificate(ctx context.Context, c client.Client, opts CertOptions) ([]byte, error) {
+	logger := log.FromContext(ctx)
+
+	if _, err := os.Stat(filepath.Join(opts.CertDir, corev1.TLSCertKey)); err == nil {
+		logger.Info("using existing webhook certificate", "dir", opts.CertDir)
+		return nil, nil
+	}
+
+	caBundle, err := writeCertificate(ctx, c, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("webhook certificate bootstrapped", "secret", opts.SecretName)
+	return caBundle, nil
+}
+
+// CertRenewer renews the bootstrapped webhook certificate while the operator runs. The
+// renewed certificate is written to CertDir, which the webhook server reloads it from, and
+// a replaced CA is injected through Injector. Client must read from the API server
+// directly, since the Secret is not in the manager cache.
+type CertRenewer struct {
+	Client   client.Client
+	Options  CertOptions
+	Injector *CAInjector
+	// Interval between checks of the certificate, an hour if zero
+	Interval time.Duration
+}
+
+var _ manager.LeaderElectionRunnable = &CertRenewer{}
+
+// NeedLeaderElection is false, since every replica serves the certificate from its own CertDir.
+func (r *CertRenewer) NeedLeaderElection() bool {
+	return false
+}
+
+func (r *CertRenewer) Start(ctx context.Context) error {
+	logger := log.FromContext(ctx).WithName("certrenewer")
+
+	interval := r.Interval
+	if interval == 0 {
+		interval = certificateCheckInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := r.renew(ctx); err != nil {
+				logger.Error(err, "failed to renew webhook certificate")
+			}
+		}
+	}
+}
+
+// renew writes the certificate in the Secret to CertDir, renewing it first if it is about
+// to expire, and injects its CA.
+func (r *CertRenewer) renew(ctx context.Context) error {
+	caBundle, err := writeCertificate(ctx, r.Client, r.Options)
+	if err != nil {
+		return err
+	}
+	return r.Injector.SetCABundle(ctx, caBundle)
+}
+
+// writeCertificate writes the certificate in the Secret to CertDir, creating or renewing it
+// first as needed, and returns the CA that issued it.
+func writeCertificate(ctx context.Context, c client.Client, opts CertOptions) ([]byte, error) {
+	secret, err := ensureSecret(ctx, c, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(opts.CertDir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create webhook certificate directory: %w", err)
+	}
+	for _, name := range []string{corev1.TLSCertKey, corev1.TLSPrivateKeyKey} {
+		if err := writeFile(filepath.Join(opts.CertDir, name), secret.Data[name]); err != nil {
+			return nil, fmt.Errorf("failed to write webhook certificate: %w", err)
+		}
+	}
+	return secret.Data["ca.crt"], nil
+}
+
+// writeFile replaces name with data unless it already holds it. The data is renamed into
+// place, so the webhook server never reads a partially written file.
+func writeFile(name string, data []byte) error {
+	if current, err := os.ReadFile(name); err == nil && bytes.Equal(current, data) {
+		return nil
+	}
+
+	fh, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(fh.Name())
+
+	if _, err := fh.Write(data); err != nil {
+		fh.Close()
+		return err
+	}
+	if err := fh.Close(); err != nil {
+		return err
+	}
+	return os.Rename(fh.Name(), name)
+}
+
+// ensureSecret returns the Secret holding the CA and the serving certificate, creating
+// or renewing them as needed.
+func ensureSecret(ctx context.Context, c client.Client, opts CertOptions) (*corev1.Secret, error) {
+	host := fmt.Sprintf("%s.%s.svc", opts.ServiceName, opts.Namespace)
+
+	var secret corev1.Secret
+	err := c.Get(ctx, client.ObjectKey{Namespace: opts.Namespace, Name: opts.SecretName}, &secret)
+	switch {
+	case apierrors.IsNotFound(err):
+		secret = corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: opts.Namespace, Name: opts.SecretName},
+			Type:       corev1.SecretTypeTLS,
+		}
+		if secret.Data, err = newCertificate(nil, host); err != nil {
+			return nil, err
+		}
+		if err := c.Create(ctx, &secret); err != nil {
+			if apierrors.IsAlreadyExists(err) {
+				// another replica created it first
+				return ensureSecret(ctx, c, opts)
+			}
+			return nil, fmt.Errorf("failed to create webhook certificate Secret: %w", err)
+		}
+		return &secret, nil
+	case err != nil:
+		return nil, fmt.Errorf("failed to get webhook certificate Secret: %w", err)
+	}
+
+	ca, err := certs.LoadCA(secret.Data["ca.crt"], secret.Data["ca.key"])
+	if err == nil && ca.ValidFor(secret.Data[corev1.TLSCertKey], host, certificateRenewal) {
+		return &secret, nil
+	}
+
+	if secret.Data, err = newCertificate(ca, host); err != nil {
+		return nil, err
+	}
+	if err := c.Update(ctx, &secret); err != nil {
+		if apierrors.IsConflict(err) {
+			// another replica renewed it first
+			return ensureSecret(ctx, c, opts)
+		}
+		return nil, fmt.Errorf("failed to renew webhook certificate: %w", err)
+	}
+	return &secret, nil
+}
+
+// newCertificate issues a serving certificate for host, creating a new CA if ca is nil or
+// expires before the certificate would, and returns the Secret data holding both.
+func newCertificate(ca *certs.CA, host string) (map[string][]byte, error) {
+	if ca == nil || time.Until(ca.Certificate.NotAfter) < certificateValidity {
+		var err error
+		if ca, err = certs.NewCA("static-operator-webhook-ca", caValidity); err != nil {
+			return nil, err
+		}
+	}
+
+	certificate, key, err := ca.IssueServer(host, []string{host}, certificateValidity)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string][]byte{
+		"ca.crt":                ca.CertificatePEM,
+		"ca.key":                ca.KeyPEM,
+		corev1.TLSCertKey:       certificate,
+		corev1.TLSPrivateKeyKey: key,
+	}, nil
+}
@@ -0,0 +1,245 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/antonjah/static/internal/certs"
+)
+
+func TestEnsureCertificate(t *testing.T) {
+	ctx := context.Background()
+	c := fake.NewClientBuilder().WithScheme(newScheme(t)).Build()
+	opts := CertOptions{
+		CertDir:     t.TempDir(),
+		ServiceName: "static-operator-webhook",
+		Namespace:   "static",
+		SecretName:  "static-operator-webhook-cert",
+	}
+	secret := func() *corev1.Secret {
+		t.Helper()
+		var secret corev1.Secret
+		if err := c.Get(ctx, client.ObjectKey{Namespace: opts.Namespace, Name: opts.SecretName}, &secret); err != nil {
+			t.Fatal(err)
+		}
+		return &secret
+	}
+
+	// the first replica generates the CA and certificate
+	caBundle, err := EnsureCertificate(ctx, c, opts)
+	if err != nil {
+		t.Fatalf("EnsureCertificate: %v", err)
+	}
+	created := secret()
+	if !bytes.Equal(caBundle, created.Data["ca.crt"]) {
+		t.Error("returned CA differs from the CA in the Secret")
+	}
+	for _, name := range []string{corev1.TLSCertKey, corev1.TLSPrivateKeyKey} {
+		data, err := os.ReadFile(filepath.Join(opts.CertDir, name))
+		if err != nil || !bytes.Equal(data, created.Data[name]) {
+			t.Errorf("%s not written from the Secret: %v", name, err)
+		}
+	}
+	ca, err := certs.LoadCA(created.Data["ca.crt"], created.Data["ca.key"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ca.ValidFor(created.Data[corev1.TLSCertKey], "static-operator-webhook.static.svc", certificateRenewal) {
+		t.Error("certificate not valid for the Service")
+	}
+
+	// other replicas serve the same certificate
+	opts.CertDir = t.TempDir()
+	if caBundle, err = EnsureCertificate(ctx, c, opts); err != nil {
+		t.Fatalf("EnsureCertificate: %v", err)
+	}
+	if !bytes.Equal(caBundle, created.Data["ca.crt"]) || !bytes.Equal(secret().Data[corev1.TLSCertKey], created.Data[corev1.TLSCertKey]) {
+		t.Error("certificate not reused")
+	}
+
+	// a certificate not valid for the Service is renewed by the same CA
+	opts.CertDir = t.TempDir()
+	opts.ServiceName = "webhook"
+	if caBundle, err = EnsureCertificate(ctx, c, opts); err != nil {
+		t.Fatalf("EnsureCertificate: %v", err)
+	}
+	renewed := secret()
+	if !bytes.Equal(caBundle, created.Data["ca.crt"]) {
+		t.Error("CA not reused")
+	}
+	if !ca.ValidFor(renewed.Data[corev1.TLSCertKey], "webhook.static.svc", certificateRenewal) {
+		t.Error("certificate not renewed for the Service")
+	}
+
+	// a CA expiring before the next certificate is replaced
+	expiring, err := certs.NewCA("static-operator-webhook-ca", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	renewed.Data["ca.crt"], renewed.Data["ca.key"] = expiring.CertificatePEM, expiring.KeyPEM
+	if err := c.Update(ctx, renewed); err != nil {
+		t.Fatal(err)
+	}
+	opts.CertDir = t.TempDir()
+	if caBundle, err = EnsureCertificate(ctx, c, opts); err != nil {
+		t.Fatalf("EnsureCertificate: %v", err)
+	}
+	if bytes.Equal(caBundle, expiring.CertificatePEM) || bytes.Equal(caBundle, created.Data["ca.crt"]) {
+		t.Error("expiring CA not replaced")
+	}
+}
+
+func TestEnsureCertificateExisting(t *testing.T) {
+	ctx := context.Background()
+	c := fake.NewClientBuilder().WithScheme(newScheme(t)).Build()
+	opts := CertOptions{
+		CertDir:     t.TempDir(),
+		ServiceName: "static-operator-webhook",
+		Namespace:   "static",
+		SecretName:  "static-operator-webhook-cert",
+	}
+	if err := os.WriteFile(filepath.Join(opts.CertDir, corev1.TLSCertKey), []byte("mounted"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	caBundle, err := EnsureCertificate(ctx, c, opts)
+	if err != nil {
+		t.Fatalf("EnsureCertificate: %v", err)
+	}
+	if caBundle != nil {
+		t.Error("CA returned for a mounted certificate")
+	}
+	var secret corev1.Secret
+	if err := c.Get(ctx, client.ObjectKey{Namespace: opts.Namespace, Name: opts.SecretName}, &secret); !apierrors.IsNotFound(err) {
+		t.Errorf("Secret created for a mounted certificate: %v", err)
+	}
+}
+
+func TestCertRenewer(t *testing.T) {
+	ctx := context.Background()
+	scheme := newScheme(t)
+	if err := admissionregistrationv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	config := &admissionregistrationv1.ValidatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{Name: "static-operator"},
+		Webhooks:   []admissionregistrationv1.ValidatingWebhook{{Name: "vstaticapi.static.io"}},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(config).Build()
+	opts := CertOptions{
+		CertDir:     t.TempDir(),
+		ServiceName: "static-operator-webhook",
+		Namespace:   "static",
+		SecretName:  "static-operator-webhook-cert",
+	}
+	host := "static-operator-webhook.static.svc"
+	secret := func() *corev1.Secret {
+		t.Helper()
+		var secret corev1.Secret
+		if err := c.Get(ctx, client.ObjectKey{Namespace: opts.Namespace, Name: opts.SecretName}, &secret); err != nil {
+			t.Fatal(err)
+		}
+		return &secret
+	}
+
+	caBundle, err := EnsureCertificate(ctx, c, opts)
+	if err != nil {
+		t.Fatalf("EnsureCertificate: %v", err)
+	}
+	r := &CertRenewer{
+		Client:   c,
+		Options:  opts,
+		Injector: &CAInjector{Client: c, ConfigName: "static-operator", CABundle: caBundle},
+	}
+
+	// replaces the certificate in the Secret and CertDir with one issued by ca, valid for an hour
+	expire := func(ca *certs.CA) {
+		t.Helper()
+		certificate, key, err := ca.IssueServer(host, []string{host}, time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expiring := secret()
+		expiring.Data = map[string][]byte{
+			"ca.crt":                ca.CertificatePEM,
+			"ca.key":                ca.KeyPEM,
+			corev1.TLSCertKey:       certificate,
+			corev1.TLSPrivateKeyKey: key,
+		}
+		if err := c.Update(ctx, expiring); err != nil {
+			t.Fatal(err)
+		}
+		for name, data := range map[string][]byte{corev1.TLSCertKey: certificate, corev1.TLSPrivateKeyKey: key} {
+			if err := os.WriteFile(filepath.Join(opts.CertDir, name), data, 0o600); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	// renews and returns the certificate in CertDir and the CA injected into the configuration
+	renew := func() ([]byte, []byte) {
+		t.Helper()
+		if err := r.renew(ctx); err != nil {
+			t.Fatalf("renew: %v", err)
+		}
+		certificate, err := os.ReadFile(filepath.Join(opts.CertDir, corev1.TLSCertKey))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var injected admissionregistrationv1.ValidatingWebhookConfiguration
+		if err := c.Get(ctx, client.ObjectKey{Name: "static-operator"}, &injected); err != nil {
+			t.Fatal(err)
+		}
+		return certificate, injected.Webhooks[0].ClientConfig.CABundle
+	}
+
+	// a valid certificate is kept
+	bootstrapped, err := os.ReadFile(filepath.Join(opts.CertDir, corev1.TLSCertKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if certificate, _ := renew(); !bytes.Equal(certificate, bootstrapped) {
+		t.Error("valid certificate renewed")
+	}
+
+	// an expiring certificate is reissued by the same CA
+	ca, err := certs.LoadCA(secret().Data["ca.crt"], secret().Data["ca.key"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	expire(ca)
+	certificate, _ := renew()
+	if !ca.ValidFor(certificate, host, certificateRenewal) {
+		t.Error("expiring certificate not renewed in CertDir")
+	}
+	if !bytes.Equal(r.Injector.CABundle, caBundle) {
+		t.Error("CA replaced on certificate renewal")
+	}
+
+	// an expiring CA is replaced and injected
+	expiring, err := certs.NewCA("static-operator-webhook-ca", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expire(expiring)
+	certificate, injected := renew()
+	if bytes.Equal(injected, expiring.CertificatePEM) || bytes.Equal(injected, caBundle) {
+		t.Error("replaced CA not injected")
+	}
+	if ca, err = certs.LoadCA(injected, secret().Data["ca.key"]); err != nil {
+		t.Fatal(err)
+	}
+	if !ca.ValidFor(certificate, host, certificateRenewal) {
+		t.Error("certificate in CertDir not issued by the injected CA")
+	}
+}
@@ -0,0 +1,47 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/antonjah/static/internal/controller"
+	staticv1alpha1 "github.com/antonjah/static/pkg/apis/static/v1alpha1"
+)
+
+// +kubebuilder:webhook:path=/validate-static-io-v1alpha1-static,mutating=false,failurePolicy=fail,sideEffects=None,groups=static.io,resources=statics,verbs=create;update,versions=v1alpha1,name=vstatic.static.io,admissionReviewVersions=v1
+
+// StaticValidator rejects Statics the operator cannot build a deployment from.
+type StaticValidator struct{}
+
+var _ admission.CustomValidator = &StaticValidator{}
+
+func (v *StaticValidator) ValidateCreate(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return nil, v.validate(obj)
+}
+
+func (v *StaticValidator) ValidateUpdate(_ context.Context, _, newObj runtime.Object) (admission.Warnings, error) {
+	return nil, v.validate(newObj)
+}
+
+func (v *StaticValidator) ValidateDelete(context.Context, runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func (v *StaticValidator) validate(obj runtime.Object) error {
+	static, ok := obj.(*staticv1alpha1.Static)
+	if !ok {
+		return fmt.Errorf("expected a Static but got %T", obj)
+	}
+	return controller.ValidateStatic(static)
+}
+
+func (v *StaticValidator) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&staticv1alpha1.Static{}).
+		WithValidator(v).
+		Complete()
+}
@@ -0,0 +1,88 @@
+package webhook
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	staticv1alpha1 "github.com/antonjah/static/pkg/apis/static/v1alpha1"
+)
+
+func TestStaticValidator(t *testing.T) {
+	tests := []struct {
+		name    string
+		obj     runtime.Object
+		wantErr string
+	}{
+		{name: "empty", obj: &staticv1alpha1.Static{}},
+		{
+			name: "secret TLS",
+			obj: &staticv1alpha1.Static{Spec: staticv1alpha1.StaticSpec{
+				TLS: &staticv1alpha1.TLSConfig{Enabled: true, SecretName: "tls", SNISecretNames: []string{"other"}},
+			}},
+		},
+		{
+			name: "secret and file TLS",
+			obj: &staticv1alpha1.Static{Spec: staticv1alpha1.StaticSpec{
+				TLS: &staticv1alpha1.TLSConfig{Enabled: true, SecretName: "tls", Certificate: "/tls/tls.crt"},
+			}},
+			wantErr: "cannot specify both secretName and file paths",
+		},
+		{
+			name: "generated and secret TLS",
+			obj: &staticv1alpha1.Static{Spec: staticv1alpha1.StaticSpec{
+				TLS: &staticv1alpha1.TLSConfig{Enabled: true, AutoGenerate: true, SecretName: "tls"},
+			}},
+			wantErr: "cannot specify autoGenerate",
+		},
+		{
+			name: "client issuer without generated TLS",
+			obj: &staticv1alpha1.Static{Spec: staticv1alpha1.StaticSpec{
+				TLS: &staticv1alpha1.TLSConfig{Enabled: true, SecretName: "tls", ClientIssuer: true},
+			}},
+			wantErr: "clientIssuer requires autoGenerate",
+		},
+		{
+			name: "SNI without default certificate",
+			obj: &staticv1alpha1.Static{Spec: staticv1alpha1.StaticSpec{
+				TLS: &staticv1alpha1.TLSConfig{Enabled: true, SNISecretNames: []string{"other"}},
+			}},
+			wantErr: "sniSecretNames require secretName",
+		},
+		{
+			name: "access log format",
+			obj: &staticv1alpha1.Static{Spec: staticv1alpha1.StaticSpec{
+				AccessLog: &staticv1alpha1.AccessLogConfig{Format: "xml"},
+			}},
+			wantErr: "unsupported accessLog format",
+		},
+		{
+			name: "api selector",
+			obj: &staticv1alpha1.Static{Spec: staticv1alpha1.StaticSpec{
+				APISelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a b"}},
+			}},
+			wantErr: "invalid apiSelector",
+		},
+		{name: "other type", obj: &corev1.Pod{}, wantErr: "expected a Static"},
+	}
+
+	v := &StaticValidator{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, createErr := v.ValidateCreate(context.Background(), tt.obj)
+			_, updateErr := v.ValidateUpdate(context.Background(), nil, tt.obj)
+			for _, err := range []error{createErr, updateErr} {
+				if tt.wantErr == "" && err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+					t.Errorf("error = %v, want error containing %q", err, tt.wantErr)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/antonjah/static/internal/controller"
+	"github.com/antonjah/static/internal/static"
+	staticv1alpha1 "github.com/antonjah/static/pkg/apis/static/v1alpha1"
+)
+
+// +kubebuilder:webhook:path=/validate-static-io-v1alpha1-staticapi,mutating=false,failurePolicy=fail,sideEffects=None,groups=static.io,resources=staticapis,verbs=create;update,versions=v1alpha1,name=vstaticapi.static.io,admissionReviewVersions=v1
+
+// StaticAPIValidator rejects StaticAPIs the static service would not load: invalid ones,
+// and ones whose path conflicts with a StaticAPI served by the same Static.
+type StaticAPIValidator struct {
+	client.Client
+}
+
+var _ admission.CustomValidator = &StaticAPIValidator{}
+
+func (v *StaticAPIValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return v.validate(ctx, obj)
+}
+
+func (v *StaticAPIValidator) ValidateUpdate(ctx context.Context, _, newObj runtime.Object) (admission.Warnings, error) {
+	return v.validate(ctx, newObj)
+}
+
+func (v *StaticAPIValidator) ValidateDelete(context.Context, runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func (v *StaticAPIValidator) validate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	staticAPI, ok := obj.(*staticv1alpha1.StaticAPI)
+	if !ok {
+		return nil, fmt.Errorf("expected a StaticAPI but got %T", obj)
+	}
+
+	if err := static.ValidateStaticAPI(*staticAPI); err != nil {
+		return nil, err
+	}
+
+	var staticAPIs staticv1alpha1.StaticAPIList
+	if err := v.List(ctx, &staticAPIs); err != nil {
+		return nil, fmt.Errorf("failed to list StaticAPIs: %w", err)
+	}
+
+	var statics staticv1alpha1.StaticList
+	if err := v.List(ctx, &statics); err != nil {
+		return nil, fmt.Errorf("failed to list Statics: %w", err)
+	}
+
+	// Unlike the static service, which serves the first of two conflicting StaticAPIs,
+	// reject any StaticAPI conflicting with one loaded by the same Static
+	pattern := static.Pattern(staticAPI.Spec.Host, staticAPI.Spec.Path)
+	servedBy, loaded := controller.LoadedWith(staticAPI, statics.Items, staticAPIs.Items)
+	for _, served := range loaded {
+		for _, other := range served {
+			if other.Namespace == staticAPI.Namespace && other.Name == staticAPI.Name {
+				continue
+			}
+			if static.ValidateStaticAPI(other) != nil || static.ConflictingPath(pattern, []string{static.Pattern(other.Spec.Host, other.Spec.Path)}) < 0 {
+				continue
+			}
+			name := other.Name
+			if other.Namespace != staticAPI.Namespace {
+				name = other.Namespace + "/" + other.Name
+			}
+			return nil, fmt.Errorf("path %s conflicts with StaticAPI %s", pattern, name)
+		}
+	}
+
+	if len(servedBy) == 0 {
+		return admission.Warnings{"no Static serves this StaticAPI"}, nil
+	}
+	return nil, nil
+}
+
+func (v *StaticAPIValidator) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&staticv1alpha1.StaticAPI{}).
+		WithValidator(v).
+		Complete()
+}
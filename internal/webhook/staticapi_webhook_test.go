@@ -0,0 +1,81 @@
+package webhook
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	staticv1alpha1 "github.com/antonjah/static/pkg/apis/static/v1alpha1"
+)
+
+func newScheme(t *testing.T) *runtime.Scheme {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := staticv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	return scheme
+}
+
+func newStaticAPI(namespace, name, path string) *staticv1alpha1.StaticAPI {
+	return &staticv1alpha1.StaticAPI{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec: staticv1alpha1.StaticAPISpec{
+			Path:    path,
+			Methods: []staticv1alpha1.Method{{Method: "GET", StatusCode: 200}},
+		},
+	}
+}
+
+func TestStaticAPIValidator(t *testing.T) {
+	static := &staticv1alpha1.Static{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "static"}}
+	v := &StaticAPIValidator{Client: fake.NewClientBuilder().
+		WithScheme(newScheme(t)).
+		WithObjects(static, newStaticAPI("default", "users", "/users/{id}")).
+		Build()}
+
+	invalid := newStaticAPI("default", "invalid", "/orders")
+	invalid.Spec.Methods[0].StatusCode = 99
+
+	tests := []struct {
+		name        string
+		obj         runtime.Object
+		wantErr     string
+		wantWarning string
+	}{
+		{name: "valid", obj: newStaticAPI("default", "orders", "/orders")},
+		{name: "update of itself", obj: newStaticAPI("default", "users", "/users/{key}")},
+		{name: "invalid", obj: invalid, wantErr: "invalid status-code"},
+		{name: "conflicting path", obj: newStaticAPI("default", "users-v2", "/users/{key}"), wantErr: "conflicts with StaticAPI users"},
+		{name: "other host", obj: func() runtime.Object {
+			staticAPI := newStaticAPI("default", "users-v2", "/users/{key}")
+			staticAPI.Spec.Host = "api.example.com"
+			return staticAPI
+		}()},
+		{name: "not served", obj: newStaticAPI("other", "users", "/users/{key}"), wantWarning: "no Static serves this StaticAPI"},
+		{name: "other type", obj: &corev1.Pod{}, wantErr: "expected a StaticAPI"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warnings, err := v.ValidateCreate(context.Background(), tt.obj)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("error = %v, want error containing %q", err, tt.wantErr)
+			}
+			if got := strings.Join(warnings, "; "); got != tt.wantWarning {
+				t.Errorf("warnings = %q, want %q", got, tt.wantWarning)
+			}
+		})
+	}
+}
@@ -11,15 +11,52 @@ type StaticSpec struct {
 	LogLevel  string                       `json:"logLevel,omitempty"`
 	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
 	TLS       *TLSConfig                   `json:"tls,omitempty"`
+	Upstream  *UpstreamConfig              `json:"upstream,omitempty"`
+	Tracing   *TracingConfig               `json:"tracing,omitempty"`
+	AccessLog *AccessLogConfig             `json:"accessLog,omitempty"`
+	// APISelector selects the StaticAPIs served by labels; all StaticAPIs are served if unset
+	APISelector *metav1.LabelSelector `json:"apiSelector,omitempty"`
+	// Namespaces StaticAPIs are served from in addition to the namespace of the Static
+	Namespaces []string `json:"namespaces,omitempty"`
 }
 
 type TLSConfig struct {
-	Enabled      bool   `json:"enabled,omitempty"`
-	SecretName   string `json:"secretName,omitempty"`
-	Certificate  string `json:"certificate,omitempty"`
-	Key          string `json:"key,omitempty"`
-	CA           string `json:"ca,omitempty"`
-	VerifyClient bool   `json:"verifyClient,omitempty"`
+	Enabled    bool   `json:"enabled,omitempty"`
+	SecretName string `json:"secretName,omitempty"`
+	// SNISecretNames are further TLS Secrets served to clients requesting one of their names
+	// through SNI, while the certificate of SecretName is served to all others
+	SNISecretNames []string `json:"sniSecretNames,omitempty"`
+	Certificate    string   `json:"certificate,omitempty"`
+	Key            string   `json:"key,omitempty"`
+	CA             string   `json:"ca,omitempty"`
+	VerifyClient   bool     `json:"verifyClient,omitempty"`
+	// AutoGenerate serves a certificate for the Service issued by an ephemeral CA
+	AutoGenerate bool `json:"autoGenerate,omitempty"`
+	// ClientIssuer issues client certificates signed by the generated CA to anyone reaching
+	// the metrics port, for mTLS testing
+	ClientIssuer bool `json:"clientIssuer,omitempty"`
+}
+
+// UpstreamConfig receives the requests not matching any StaticAPI
+type UpstreamConfig struct {
+	// URL requests are proxied to when none of the prefixes match
+	URL string `json:"url,omitempty"`
+	// Prefixes maps path prefixes to the URL their requests are proxied to
+	Prefixes map[string]string `json:"prefixes,omitempty"`
+}
+
+// TracingConfig exports a span per request over OTLP/HTTP
+type TracingConfig struct {
+	// Endpoint is the base URL of the OTLP/HTTP collector, e.g. http://otel-collector:4318
+	Endpoint string `json:"endpoint,omitempty"`
+	// ResponseHeaders writes the traceparent and baggage of the request span to responses
+	ResponseHeaders bool `json:"responseHeaders,omitempty"`
+}
+
+// AccessLogConfig writes an access log to the standard output of the static service
+type AccessLogConfig struct {
+	// Format of the access log: json (default), combined or logfmt
+	Format string `json:"format,omitempty"`
 }
 
 type StaticStatus struct {
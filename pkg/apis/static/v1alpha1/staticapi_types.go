@@ -1,10 +1,15 @@
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
 type StaticAPISpec struct {
+	// Host restricts the StaticAPI to requests for the host, taking precedence over
+	// StaticAPIs without a host serving the same path
+	Host    string   `json:"host,omitempty"`
 	Path    string   `json:"path"`
 	Methods []Method `json:"methods"`
 }
@@ -16,14 +21,172 @@ type Method struct {
 	StatusCode int               `json:"statusCode" yaml:"status-code"`
 	Body       string            `json:"body,omitempty" yaml:"body,omitempty"`
 	Headers    map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	// BodyFile is read instead of Body, relative to STATICAPIS_PATH
+	BodyFile string `json:"bodyFile,omitempty" yaml:"body-file,omitempty"`
+	// BodyEncoding "base64" decodes Body before it is written, for binary content
+	// +kubebuilder:validation:Enum=base64
+	BodyEncoding string `json:"bodyEncoding,omitempty" yaml:"body-encoding,omitempty"`
+	// BodyFrom reads the body from a ConfigMap or Secret key instead of Body
+	BodyFrom *BodySource `json:"bodyFrom,omitempty" yaml:"body-from,omitempty"`
+	// Responses are evaluated in order and the first one matching the request is
+	// returned. StatusCode, Body and Headers are used when none match.
+	Responses []Response `json:"responses,omitempty" yaml:"responses,omitempty"`
+	// Sequence is returned instead of the default response, one response per request.
+	// SequenceMode decides what happens after the last one: "stick" keeps returning it,
+	// "loop" starts over and "random" picks a response by weight.
+	Sequence []Response `json:"sequence,omitempty" yaml:"sequence,omitempty"`
+	// +kubebuilder:validation:Enum=stick;loop;random
+	SequenceMode string `json:"sequenceMode,omitempty" yaml:"sequence-mode,omitempty"`
+	// ExpandParams replaces "{name}" placeholders of the path parameters in body and header values
+	ExpandParams bool `json:"expandParams,omitempty" yaml:"expand-params,omitempty"`
+	// Template enables rendering body and header values as Go templates with access to the request
+	Template bool `json:"template,omitempty" yaml:"template,omitempty"`
+	// Fault injects latency and failures into the responses
+	Fault *Fault `json:"fault,omitempty" yaml:"fault,omitempty"`
+	// Validation rejects requests not satisfying the given JSON schemas
+	Validation *Validation `json:"validation,omitempty" yaml:"validation,omitempty"`
 }
 
+// BodySourceLabel must be set to "true" on the ConfigMaps and Secrets bodies are read from,
+// so StaticAPIs cannot serve other Secrets of their namespace
+const BodySourceLabel = "static.io/body-source"
+
+// BodySource selects a key of a ConfigMap or Secret in the namespace of the StaticAPI
+// labeled with BodySourceLabel
+type BodySource struct {
+	ConfigMapKeyRef *corev1.ConfigMapKeySelector `json:"configMapKeyRef,omitempty" yaml:"config-map-key-ref,omitempty"`
+	SecretKeyRef    *corev1.SecretKeySelector    `json:"secretKeyRef,omitempty" yaml:"secret-key-ref,omitempty"`
+}
+
+// Validation schemas are JSON schemas. Params, Query and Headers are object schemas
+// with a property per parameter, Body is the schema of a JSON request body.
+type Validation struct {
+	Params  *runtime.RawExtension `json:"params,omitempty" yaml:"params,omitempty"`
+	Query   *runtime.RawExtension `json:"query,omitempty" yaml:"query,omitempty"`
+	Headers *runtime.RawExtension `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Body    *runtime.RawExtension `json:"body,omitempty" yaml:"body,omitempty"`
+	// StatusCode of the response to invalid requests (default: 400)
+	// +kubebuilder:validation:Minimum=100
+	// +kubebuilder:validation:Maximum=599
+	StatusCode int `json:"statusCode,omitempty" yaml:"status-code,omitempty"`
+}
+
+// Fault percentages are in the range 0-100
+type Fault struct {
+	Delay *Delay `json:"delay,omitempty" yaml:"delay,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	ErrorPercent int `json:"errorPercent,omitempty" yaml:"error-percent,omitempty"`
+	// +kubebuilder:validation:Minimum=100
+	// +kubebuilder:validation:Maximum=599
+	ErrorStatus int `json:"errorStatus,omitempty" yaml:"error-status,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	AbortPercent int `json:"abortPercent,omitempty" yaml:"abort-percent,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	TruncatePercent int              `json:"truncatePercent,omitempty" yaml:"truncate-percent,omitempty"`
+	Stall           *metav1.Duration `json:"stall,omitempty" yaml:"stall,omitempty"`
+}
+
+// Delay is Fixed plus a duration sampled from Distribution:
+// "uniform" between Min and Max, or "normal"/"lognormal" with Mean and StdDev
+type Delay struct {
+	Fixed *metav1.Duration `json:"fixed,omitempty" yaml:"fixed,omitempty"`
+	// +kubebuilder:validation:Enum=uniform;normal;lognormal
+	Distribution string           `json:"distribution,omitempty" yaml:"distribution,omitempty"`
+	Min          *metav1.Duration `json:"min,omitempty" yaml:"min,omitempty"`
+	Max          *metav1.Duration `json:"max,omitempty" yaml:"max,omitempty"`
+	Mean         *metav1.Duration `json:"mean,omitempty" yaml:"mean,omitempty"`
+	StdDev       *metav1.Duration `json:"stdDev,omitempty" yaml:"stddev,omitempty"`
+}
+
+type Response struct {
+	Match ResponseMatch `json:"match,omitempty" yaml:"match,omitempty"`
+	// +kubebuilder:validation:Minimum=100
+	// +kubebuilder:validation:Maximum=599
+	StatusCode int               `json:"statusCode" yaml:"status-code"`
+	Body       string            `json:"body,omitempty" yaml:"body,omitempty"`
+	Headers    map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	BodyFile   string            `json:"bodyFile,omitempty" yaml:"body-file,omitempty"`
+	// +kubebuilder:validation:Enum=base64
+	BodyEncoding string      `json:"bodyEncoding,omitempty" yaml:"body-encoding,omitempty"`
+	BodyFrom     *BodySource `json:"bodyFrom,omitempty" yaml:"body-from,omitempty"`
+	// Weight of the response in a random sequence (default: 1)
+	// +kubebuilder:validation:Minimum=0
+	Weight int `json:"weight,omitempty" yaml:"weight,omitempty"`
+}
+
+type ResponseMatch struct {
+	Query   map[string]ValueMatch `json:"query,omitempty" yaml:"query,omitempty"`
+	Headers map[string]ValueMatch `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Cookies map[string]ValueMatch `json:"cookies,omitempty" yaml:"cookies,omitempty"`
+	Body    *BodyMatch            `json:"body,omitempty" yaml:"body,omitempty"`
+	// ClientCertificate matches the verified TLS client certificate of the request
+	ClientCertificate *ClientCertificateMatch `json:"clientCertificate,omitempty" yaml:"client-certificate,omitempty"`
+}
+
+type ValueMatch struct {
+	Equals string `json:"equals,omitempty" yaml:"equals,omitempty"`
+	Regex  string `json:"regex,omitempty" yaml:"regex,omitempty"`
+}
+
+type BodyMatch struct {
+	Equals   string          `json:"equals,omitempty" yaml:"equals,omitempty"`
+	Regex    string          `json:"regex,omitempty" yaml:"regex,omitempty"`
+	JSONPath []JSONPathMatch `json:"jsonPath,omitempty" yaml:"json-path,omitempty"`
+}
+
+type ClientCertificateMatch struct {
+	CommonName *ValueMatch `json:"commonName,omitempty" yaml:"common-name,omitempty"`
+	// OrganizationalUnit matches if any organizational unit of the subject matches
+	OrganizationalUnit *ValueMatch `json:"organizationalUnit,omitempty" yaml:"organizational-unit,omitempty"`
+	// SAN matches if any DNS name, email address, IP address or URI of the certificate matches
+	SAN *ValueMatch `json:"san,omitempty" yaml:"san,omitempty"`
+	// Fingerprint is the hex encoded SHA-256 digest of the certificate, colons are ignored
+	Fingerprint string `json:"fingerprint,omitempty" yaml:"fingerprint,omitempty"`
+}
+
+type JSONPathMatch struct {
+	Path   string `json:"path" yaml:"path"`
+	Equals string `json:"equals" yaml:"equals"`
+}
+
+// StaticAPI condition types
+const (
+	// ConditionAccepted is true when the StaticAPI is valid and its path does not conflict
+	ConditionAccepted = "Accepted"
+	// ConditionConflicting is true when the path conflicts with another StaticAPI
+	ConditionConflicting = "Conflicting"
+	// ConditionInvalid is true when the StaticAPI fails validation
+	ConditionInvalid = "Invalid"
+)
+
 type StaticAPIStatus struct {
+	// ObservedGeneration is the generation of the spec the status was computed for
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Ready is true when the StaticAPI is accepted and served by at least one Static
+	Ready bool `json:"ready,omitempty"`
+	// Message describes why the StaticAPI is or is not served
+	Message string `json:"message,omitempty"`
+	// Methods lists the methods of the StaticAPI, e.g. "GET,POST"
+	Methods string `json:"methods,omitempty"`
+	// ServedBy lists the Static instances serving the StaticAPI
+	ServedBy []string `json:"servedBy,omitempty"`
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
 // +kubebuilder:resource:scope=Namespaced,shortName=sapi
+// +kubebuilder:printcolumn:name="Host",type=string,JSONPath=`.spec.host`
+// +kubebuilder:printcolumn:name="Path",type=string,JSONPath=`.spec.path`
+// +kubebuilder:printcolumn:name="Methods",type=string,JSONPath=`.status.methods`
+// +kubebuilder:printcolumn:name="Ready",type=boolean,JSONPath=`.status.ready`
+// +kubebuilder:printcolumn:name="Message",type=string,JSONPath=`.status.message`,priority=1
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 type StaticAPI struct {
 	metav1.TypeMeta   `json:",inline"`
@@ -5,9 +5,166 @@ package v1alpha1
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
+func (in *AccessLogConfig) DeepCopyInto(out *AccessLogConfig) {
+	*out = *in
+}
+
+func (in *AccessLogConfig) DeepCopy() *AccessLogConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(AccessLogConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *BodyMatch) DeepCopyInto(out *BodyMatch) {
+	*out = *in
+	if in.JSONPath != nil {
+		in, out := &in.JSONPath, &out.JSONPath
+		*out = make([]JSONPathMatch, len(*in))
+		copy(*out, *in)
+	}
+}
+
+func (in *BodyMatch) DeepCopy() *BodyMatch {
+	if in == nil {
+		return nil
+	}
+	out := new(BodyMatch)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *BodySource) DeepCopyInto(out *BodySource) {
+	*out = *in
+	if in.ConfigMapKeyRef != nil {
+		in, out := &in.ConfigMapKeyRef, &out.ConfigMapKeyRef
+		*out = new(corev1.ConfigMapKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SecretKeyRef != nil {
+		in, out := &in.SecretKeyRef, &out.SecretKeyRef
+		*out = new(corev1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+func (in *BodySource) DeepCopy() *BodySource {
+	if in == nil {
+		return nil
+	}
+	out := new(BodySource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *ClientCertificateMatch) DeepCopyInto(out *ClientCertificateMatch) {
+	*out = *in
+	if in.CommonName != nil {
+		in, out := &in.CommonName, &out.CommonName
+		*out = new(ValueMatch)
+		**out = **in
+	}
+	if in.OrganizationalUnit != nil {
+		in, out := &in.OrganizationalUnit, &out.OrganizationalUnit
+		*out = new(ValueMatch)
+		**out = **in
+	}
+	if in.SAN != nil {
+		in, out := &in.SAN, &out.SAN
+		*out = new(ValueMatch)
+		**out = **in
+	}
+}
+
+func (in *ClientCertificateMatch) DeepCopy() *ClientCertificateMatch {
+	if in == nil {
+		return nil
+	}
+	out := new(ClientCertificateMatch)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *Delay) DeepCopyInto(out *Delay) {
+	*out = *in
+	if in.Fixed != nil {
+		in, out := &in.Fixed, &out.Fixed
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.Min != nil {
+		in, out := &in.Min, &out.Min
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.Max != nil {
+		in, out := &in.Max, &out.Max
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.Mean != nil {
+		in, out := &in.Mean, &out.Mean
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.StdDev != nil {
+		in, out := &in.StdDev, &out.StdDev
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+func (in *Delay) DeepCopy() *Delay {
+	if in == nil {
+		return nil
+	}
+	out := new(Delay)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *Fault) DeepCopyInto(out *Fault) {
+	*out = *in
+	if in.Delay != nil {
+		in, out := &in.Delay, &out.Delay
+		*out = new(Delay)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Stall != nil {
+		in, out := &in.Stall, &out.Stall
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+func (in *Fault) DeepCopy() *Fault {
+	if in == nil {
+		return nil
+	}
+	out := new(Fault)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *JSONPathMatch) DeepCopyInto(out *JSONPathMatch) {
+	*out = *in
+}
+
+func (in *JSONPathMatch) DeepCopy() *JSONPathMatch {
+	if in == nil {
+		return nil
+	}
+	out := new(JSONPathMatch)
+	in.DeepCopyInto(out)
+	return out
+}
+
 func (in *Method) DeepCopyInto(out *Method) {
 	*out = *in
 	if in.Headers != nil {
@@ -17,6 +174,35 @@ func (in *Method) DeepCopyInto(out *Method) {
 			(*out)[key] = val
 		}
 	}
+	if in.BodyFrom != nil {
+		in, out := &in.BodyFrom, &out.BodyFrom
+		*out = new(BodySource)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Responses != nil {
+		in, out := &in.Responses, &out.Responses
+		*out = make([]Response, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Sequence != nil {
+		in, out := &in.Sequence, &out.Sequence
+		*out = make([]Response, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Fault != nil {
+		in, out := &in.Fault, &out.Fault
+		*out = new(Fault)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Validation != nil {
+		in, out := &in.Validation, &out.Validation
+		*out = new(Validation)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 func (in *Method) DeepCopy() *Method {
@@ -28,7 +214,77 @@ func (in *Method) DeepCopy() *Method {
 	return out
 }
 
-func (in *StaticAPI) DeepCopyInto(out *StaticAPI) {
+func (in *Response) DeepCopyInto(out *Response) {
+	*out = *in
+	in.Match.DeepCopyInto(&out.Match)
+	if in.Headers != nil {
+		in, out := &in.Headers, &out.Headers
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.BodyFrom != nil {
+		in, out := &in.BodyFrom, &out.BodyFrom
+		*out = new(BodySource)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+func (in *Response) DeepCopy() *Response {
+	if in == nil {
+		return nil
+	}
+	out := new(Response)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *ResponseMatch) DeepCopyInto(out *ResponseMatch) {
+	*out = *in
+	if in.Query != nil {
+		in, out := &in.Query, &out.Query
+		*out = make(map[string]ValueMatch, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Headers != nil {
+		in, out := &in.Headers, &out.Headers
+		*out = make(map[string]ValueMatch, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Cookies != nil {
+		in, out := &in.Cookies, &out.Cookies
+		*out = make(map[string]ValueMatch, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Body != nil {
+		in, out := &in.Body, &out.Body
+		*out = new(BodyMatch)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ClientCertificate != nil {
+		in, out := &in.ClientCertificate, &out.ClientCertificate
+		*out = new(ClientCertificateMatch)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+func (in *ResponseMatch) DeepCopy() *ResponseMatch {
+	if in == nil {
+		return nil
+	}
+	out := new(ResponseMatch)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *Static) DeepCopyInto(out *Static) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
@@ -36,6 +292,30 @@ func (in *StaticAPI) DeepCopyInto(out *StaticAPI) {
 	out.Status = in.Status
 }
 
+func (in *Static) DeepCopy() *Static {
+	if in == nil {
+		return nil
+	}
+	out := new(Static)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *Static) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+func (in *StaticAPI) DeepCopyInto(out *StaticAPI) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
 func (in *StaticAPI) DeepCopy() *StaticAPI {
 	if in == nil {
 		return nil
@@ -103,6 +383,18 @@ func (in *StaticAPISpec) DeepCopy() *StaticAPISpec {
 
 func (in *StaticAPIStatus) DeepCopyInto(out *StaticAPIStatus) {
 	*out = *in
+	if in.ServedBy != nil {
+		in, out := &in.ServedBy, &out.ServedBy
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 func (in *StaticAPIStatus) DeepCopy() *StaticAPIStatus {
@@ -114,30 +406,6 @@ func (in *StaticAPIStatus) DeepCopy() *StaticAPIStatus {
 	return out
 }
 
-func (in *Static) DeepCopyInto(out *Static) {
-	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
-}
-
-func (in *Static) DeepCopy() *Static {
-	if in == nil {
-		return nil
-	}
-	out := new(Static)
-	in.DeepCopyInto(out)
-	return out
-}
-
-func (in *Static) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
-	}
-	return nil
-}
-
 func (in *StaticList) DeepCopyInto(out *StaticList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
@@ -182,8 +450,33 @@ func (in *StaticSpec) DeepCopyInto(out *StaticSpec) {
 	if in.TLS != nil {
 		in, out := &in.TLS, &out.TLS
 		*out = new(TLSConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Upstream != nil {
+		in, out := &in.Upstream, &out.Upstream
+		*out = new(UpstreamConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Tracing != nil {
+		in, out := &in.Tracing, &out.Tracing
+		*out = new(TracingConfig)
+		**out = **in
+	}
+	if in.AccessLog != nil {
+		in, out := &in.AccessLog, &out.AccessLog
+		*out = new(AccessLogConfig)
 		**out = **in
 	}
+	if in.APISelector != nil {
+		in, out := &in.APISelector, &out.APISelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 func (in *StaticSpec) DeepCopy() *StaticSpec {
@@ -210,6 +503,11 @@ func (in *StaticStatus) DeepCopy() *StaticStatus {
 
 func (in *TLSConfig) DeepCopyInto(out *TLSConfig) {
 	*out = *in
+	if in.SNISecretNames != nil {
+		in, out := &in.SNISecretNames, &out.SNISecretNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 func (in *TLSConfig) DeepCopy() *TLSConfig {
@@ -220,3 +518,82 @@ func (in *TLSConfig) DeepCopy() *TLSConfig {
 	in.DeepCopyInto(out)
 	return out
 }
+
+func (in *TracingConfig) DeepCopyInto(out *TracingConfig) {
+	*out = *in
+}
+
+func (in *TracingConfig) DeepCopy() *TracingConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(TracingConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *UpstreamConfig) DeepCopyInto(out *UpstreamConfig) {
+	*out = *in
+	if in.Prefixes != nil {
+		in, out := &in.Prefixes, &out.Prefixes
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+func (in *UpstreamConfig) DeepCopy() *UpstreamConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(UpstreamConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *Validation) DeepCopyInto(out *Validation) {
+	*out = *in
+	if in.Params != nil {
+		in, out := &in.Params, &out.Params
+		*out = new(runtime.RawExtension)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Query != nil {
+		in, out := &in.Query, &out.Query
+		*out = new(runtime.RawExtension)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Headers != nil {
+		in, out := &in.Headers, &out.Headers
+		*out = new(runtime.RawExtension)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Body != nil {
+		in, out := &in.Body, &out.Body
+		*out = new(runtime.RawExtension)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+func (in *Validation) DeepCopy() *Validation {
+	if in == nil {
+		return nil
+	}
+	out := new(Validation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *ValueMatch) DeepCopyInto(out *ValueMatch) {
+	*out = *in
+}
+
+func (in *ValueMatch) DeepCopy() *ValueMatch {
+	if in == nil {
+		return nil
+	}
+	out := new(ValueMatch)
+	in.DeepCopyInto(out)
+	return out
+}